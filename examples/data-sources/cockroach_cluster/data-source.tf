@@ -0,0 +1,5 @@
+data "cockroach_cluster" "example" {}
+
+locals {
+  cluster_regions = distinct([for node in data.cockroach_cluster.example.nodes : node.locality_tiers["region"] if contains(keys(node.locality_tiers), "region")])
+}
@@ -0,0 +1,15 @@
+data "cockroach_cluster_settings" "kv" {
+  prefix = "kv."
+}
+
+resource "cockroach_database" "changefeeds" {
+  name       = "changefeeds"
+  local_port = "26258"
+
+  lifecycle {
+    precondition {
+      condition     = data.cockroach_cluster_settings.kv.values["kv.rangefeed.enabled"] == "true"
+      error_message = "Changefeeds require the kv.rangefeed.enabled cluster setting."
+    }
+  }
+}
@@ -1,3 +1,7 @@
 data "cockroach_database" "example" {
   name = "foo"
 }
+
+output "example_tables" {
+  value = data.cockroach_database.example.tables
+}
@@ -0,0 +1,5 @@
+data "cockroach_database_backup_verification" "example" {
+  schedule_id  = cockroach_database_backup.example.id
+  test_restore = true
+  local_port   = "26261"
+}
@@ -0,0 +1,12 @@
+data "cockroach_databases" "example" {
+  name_regex = "^app_"
+}
+
+resource "cockroach_database_backup" "example" {
+  for_each = { for db in data.cockroach_databases.example.databases : db.name => db }
+
+  name             = "${each.key}_daily"
+  backup_path      = "nodelocal://0/${each.key}"
+  database_name    = each.key
+  backup_recurring = "@daily"
+}
@@ -0,0 +1,17 @@
+data "cockroach_grants" "example" {
+  object_type      = "table"
+  database         = "app"
+  schema           = "public"
+  name             = "accounts"
+  grantee          = "reporting"
+  expand_inherited = true
+}
+
+output "reporting_privileges" {
+  value = distinct([for grant in data.cockroach_grants.example.grants : grant.privilege])
+}
+
+data "cockroach_grants" "reporting" {
+  database = "app"
+  grantee  = "reporting"
+}
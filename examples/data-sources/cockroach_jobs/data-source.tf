@@ -0,0 +1,9 @@
+data "cockroach_jobs" "failed_backups" {
+  type          = "BACKUP"
+  status        = "failed"
+  created_after = "2022-10-01T00:00:00Z"
+}
+
+output "failed_backup_errors" {
+  value = [for job in data.cockroach_jobs.failed_backups.jobs : "${job.id}: ${job.error}"]
+}
@@ -0,0 +1,16 @@
+data "cockroach_regions" "example" {}
+
+resource "cockroach_database" "multi_region" {
+  name           = "multi_region"
+  primary_region = data.cockroach_regions.example.names[0]
+  regions        = slice(data.cockroach_regions.example.names, 1, length(data.cockroach_regions.example.names))
+  local_port     = "26258"
+}
+
+data "cockroach_regions" "multi_region" {
+  database = cockroach_database.multi_region.name
+}
+
+output "multi_region_regions" {
+  value = data.cockroach_regions.multi_region.non_primary_names
+}
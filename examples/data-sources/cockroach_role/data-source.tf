@@ -0,0 +1,7 @@
+data "cockroach_role" "example" {
+  name = "operator_managed_user"
+}
+
+output "example_can_login" {
+  value = data.cockroach_role.example.can_login
+}
@@ -0,0 +1,8 @@
+data "cockroach_roles" "example" {
+  member_of = "admin"
+  can_login = "true"
+}
+
+output "example_admins" {
+  value = data.cockroach_roles.example.roles[*].name
+}
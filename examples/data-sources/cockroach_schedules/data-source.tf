@@ -0,0 +1,7 @@
+data "cockroach_schedules" "backups" {
+  type = "backup"
+}
+
+output "paused_backup_schedules" {
+  value = [for schedule in data.cockroach_schedules.backups.schedules : schedule.label if schedule.paused]
+}
@@ -0,0 +1,10 @@
+data "cockroach_sql_query" "example" {
+  query             = "SELECT id, value FROM config WHERE name = $1"
+  parameters        = ["max_connections"]
+  database          = "app"
+  as_of_system_time = "follower_read_timestamp()"
+}
+
+output "max_connections" {
+  value = data.cockroach_sql_query.example.rows[0].value
+}
@@ -0,0 +1,15 @@
+data "cockroach_tables" "events" {
+  database   = "app"
+  schema     = "public"
+  name_regex = "^events_"
+}
+
+resource "cockroach_table_locality" "events" {
+  for_each = { for table in data.cockroach_tables.events.tables : table.name => table }
+
+  database   = "app"
+  schema     = each.value.schema
+  table      = each.value.name
+  locality   = "regional_by_row"
+  local_port = "26262"
+}
@@ -0,0 +1,11 @@
+resource "cockroach_client_certificate" "example" {
+  username = cockroach_user.example.username
+
+  ca_secret {
+    name = "cockroachdb-ca"
+  }
+
+  key_algorithm         = "ECDSA"
+  validity_period_hours = 8760
+  early_renewal_hours   = 720
+}
@@ -1,9 +1,10 @@
 resource "cockroach_user" "example" {
-  username   = "example_user"
-  password   = "example_password"
-  roles      = "SQLLOGIN VIEWACTIVITY (other roles)"
-  is_admin   = false
-  local_port = "26257"
+  username     = "example_user"
+  password     = "example_password"
+  viewactivity = true
+  valid_until  = "2030-01-01 00:00:00+00:00"
+  is_admin     = false
+  local_port   = "26257"
 }
 
 resource "cockroach_database" "example" {
@@ -0,0 +1,2 @@
+# HBA configuration can be imported using the name of the cluster setting
+terraform import cockroach_hba_configuration.example server.host_based_authentication.configuration
@@ -0,0 +1,21 @@
+resource "cockroach_hba_configuration" "example" {
+  rule {
+    type    = "hostssl"
+    user    = "example_app"
+    address = "10.0.0.0/8"
+    method  = "cert"
+  }
+
+  rule {
+    type    = "host"
+    user    = "example_user"
+    address = "all"
+    method  = "cert-password"
+  }
+
+  rule {
+    type    = "host"
+    address = "all"
+    method  = "reject"
+  }
+}
@@ -0,0 +1,2 @@
+# Session settings can be imported using <role>/<database>, leave a part empty for all roles or all databases
+terraform import cockroach_session_settings.example example_user/example_database
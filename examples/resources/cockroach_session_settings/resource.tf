@@ -0,0 +1,10 @@
+resource "cockroach_session_settings" "example" {
+  role     = cockroach_user.example.username
+  database = cockroach_database.example.name
+
+  settings = {
+    statement_timeout                      = "30s"
+    default_transaction_use_follower_reads = "on"
+    search_path                            = "app, public"
+  }
+}
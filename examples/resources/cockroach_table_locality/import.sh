@@ -0,0 +1,2 @@
+# Table locality can be imported using <database>/<schema>/<table>
+terraform import cockroach_table_locality.example example_database/public/users
@@ -0,0 +1,7 @@
+resource "cockroach_table_locality" "example" {
+  database   = cockroach_database.example.name
+  schema     = "public"
+  table      = "users"
+  locality   = "regional_by_row"
+  local_port = "26262"
+}
@@ -1,7 +1,34 @@
 resource "cockroach_user" "example" {
-  username   = "example_user"
-  password   = "example_password"
-  roles      = "SQLLOGIN VIEWACTIVITY (other roles)"
-  is_admin   = false
+  username     = "example_user"
+  password     = "example_password"
+  viewactivity = true
+  valid_until  = "2030-01-01 00:00:00+00:00"
+  is_admin     = false
+  local_port   = "26257"
+}
+
+resource "cockroach_user" "generated" {
+  username = "example_app"
+
+  generate_password {
+    length  = 32
+    special = false
+  }
+
+  rotate_after          = "720h"
+  rotation_grace_period = "24h"
+  local_port            = "26257"
+}
+
+resource "cockroach_user" "application" {
+  username = "example_service"
+
+  generate_password {}
+
+  connection_parameters {
+    database    = "orders"
+    sslrootcert = "/cockroach/certs/ca.crt"
+  }
+
   local_port = "26257"
 }
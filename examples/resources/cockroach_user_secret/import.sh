@@ -0,0 +1,2 @@
+# User secret can be imported using <namespace>/<name>
+terraform import cockroach_user_secret.example apps/example-app-credentials
@@ -0,0 +1,16 @@
+resource "cockroach_user_secret" "example" {
+  name      = "example-app-credentials"
+  namespace = "apps"
+
+  username       = cockroach_user.application.username
+  password       = cockroach_user.application.generated_password
+  connection_uri = cockroach_user.application.connection_uri
+
+  cert_pem        = cockroach_client_certificate.example.cert_pem
+  private_key_pem = cockroach_client_certificate.example.private_key_pem
+  ca_cert_pem     = cockroach_client_certificate.example.ca_cert_pem
+
+  labels = {
+    "app.kubernetes.io/name" = "example-app"
+  }
+}
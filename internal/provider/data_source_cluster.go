@@ -0,0 +1,271 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	clusterIdAttr                = "cluster_id"
+	clusterOrganizationAttr      = "organization"
+	clusterServerVersionAttr     = "server_version"
+	clusterActiveVersionAttr     = "active_version"
+	clusterEnterpriseLicenseAttr = "enterprise_license"
+	clusterNodeCountAttr         = "node_count"
+	clusterNodesAttr             = "nodes"
+
+	nodeIdAttr            = "node_id"
+	nodeAddressAttr       = "address"
+	nodeSqlAddressAttr    = "sql_address"
+	nodeLocalityAttr      = "locality"
+	nodeLocalityTiersAttr = "locality_tiers"
+	nodeServerVersionAttr = "server_version"
+	nodeBuildTagAttr      = "build_tag"
+	nodeIsLiveAttr        = "is_live"
+	nodeIsDrainingAttr    = "is_draining"
+	nodeMembershipAttr    = "membership"
+)
+
+func dataSourceCluster() *schema.Resource {
+	return &schema.Resource{
+		// This description is used by the documentation generator and the language server.
+		Description: "Data source used to read the identity, version and node topology of the Cockroachdb cluster.",
+
+		ReadContext: dataSourceClusterRead,
+
+		Schema: map[string]*schema.Schema{
+			clusterIdAttr: {
+				Description: "ID of the cluster.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			clusterOrganizationAttr: {
+				Description: "Organization of the cluster, the `cluster.organization` setting.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			clusterServerVersionAttr: {
+				Description: "Version of the node the provider is connected to, for example `v22.1.8`.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			clusterActiveVersionAttr: {
+				Description: "Active cluster version, the `version` setting, for example `22.1`.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			clusterEnterpriseLicenseAttr: {
+				Description: "True if an enterprise license is installed.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			clusterNodeCountAttr: {
+				Description: "Number of nodes of the cluster, decommissioned nodes excluded.",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+			clusterNodesAttr: {
+				Description: "Nodes of the cluster ordered by ID, decommissioned nodes excluded.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						nodeIdAttr: {
+							Description: "ID of the node.",
+							Type:        schema.TypeInt,
+							Computed:    true,
+						},
+						nodeAddressAttr: {
+							Description: "Address the node advertises to the other nodes.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						nodeSqlAddressAttr: {
+							Description: "Address the node serves SQL clients on.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						nodeLocalityAttr: {
+							Description: "Locality of the node, for example `region=us-east1,zone=us-east1-b`.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						nodeLocalityTiersAttr: {
+							Description: "Tiers of the locality of the node, keyed by tier name.",
+							Type:        schema.TypeMap,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						nodeServerVersionAttr: {
+							Description: "Cluster version the node runs.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						nodeBuildTagAttr: {
+							Description: "Build tag of the binary of the node.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						nodeIsLiveAttr: {
+							Description: "True if the node is live.",
+							Type:        schema.TypeBool,
+							Computed:    true,
+						},
+						nodeIsDrainingAttr: {
+							Description: "True if the node is draining.",
+							Type:        schema.TypeBool,
+							Computed:    true,
+						},
+						nodeMembershipAttr: {
+							Description: "Membership of the node, `active` or `decommissioning`.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+					},
+				},
+			},
+			argLocalPort: {
+				Description: "Local port to be used for port-forward. (default is 26268), use different port to avoid same port opening.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "26268",
+			},
+		},
+	}
+}
+
+func dataSourceClusterRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	local_port := d.Get(argLocalPort).(string)
+	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
+
+	// stopCh control the port forwarding lifecycle. When it gets closed the
+	// port forward will terminate
+	stopCh := make(chan struct{}, 1)
+	// readyCh communicate when the port forward is ready to get traffic
+	readyCh := make(chan struct{})
+
+	tryPortForwardIfNeeded(ctx, d, meta, stopCh, readyCh, local_port)
+
+	conn, err := pgx.Connect(ctx, dns)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := conn.Ping(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+
+	var (
+		cluster_id     string
+		organization   string
+		server_version string
+		active_version string
+		license        string
+	)
+	err = conn.QueryRow(ctx,
+		`SELECT crdb_internal.cluster_id()::STRING,
+			(SELECT value FROM crdb_internal.node_build_info WHERE field = 'Organization'),
+			(SELECT value FROM crdb_internal.node_build_info WHERE field = 'Version'),
+			(SELECT value FROM crdb_internal.cluster_settings WHERE variable = 'version'),
+			(SELECT value FROM crdb_internal.cluster_settings WHERE variable = 'enterprise.license')`,
+	).Scan(&cluster_id, &organization, &server_version, &active_version, &license)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	rows, err := conn.Query(ctx,
+		`SELECT n.node_id, n.address, n.sql_address, n.locality, n.server_version, n.build_tag, n.is_live, l.draining, l.membership
+		FROM crdb_internal.gossip_nodes AS n
+		LEFT JOIN crdb_internal.gossip_liveness AS l ON l.node_id = n.node_id
+		WHERE l.membership IS NULL OR l.membership != 'decommissioned'
+		ORDER BY n.node_id`,
+	)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer rows.Close()
+
+	nodes := []interface{}{}
+	for rows.Next() {
+		var (
+			node_id             int
+			address             string
+			sql_address         string
+			locality            string
+			node_server_version string
+			build_tag           string
+			is_live             bool
+			is_draining         sql.NullBool
+			membership          sql.NullString
+		)
+		if err := rows.Scan(&node_id, &address, &sql_address, &locality, &node_server_version, &build_tag, &is_live, &is_draining, &membership); err != nil {
+			return diag.FromErr(err)
+		}
+
+		nodes = append(nodes, map[string]interface{}{
+			nodeIdAttr:            node_id,
+			nodeAddressAttr:       address,
+			nodeSqlAddressAttr:    sql_address,
+			nodeLocalityAttr:      locality,
+			nodeLocalityTiersAttr: parseLocality(locality),
+			nodeServerVersionAttr: node_server_version,
+			nodeBuildTagAttr:      build_tag,
+			nodeIsLiveAttr:        is_live,
+			nodeIsDrainingAttr:    is_draining.Bool,
+			nodeMembershipAttr:    membership.String,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(cluster_id)
+	if err := d.Set(clusterIdAttr, cluster_id); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(clusterOrganizationAttr, organization); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(clusterServerVersionAttr, server_version); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(clusterActiveVersionAttr, active_version); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(clusterEnterpriseLicenseAttr, license != ""); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(clusterNodeCountAttr, len(nodes)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(clusterNodesAttr, nodes); err != nil {
+		return diag.FromErr(err)
+	}
+
+	close(stopCh)
+
+	return diag.Diagnostics{}
+}
+
+// parseLocality splits a locality such as region=us-east1,zone=us-east1-b
+// into its tiers.
+func parseLocality(locality string) map[string]string {
+	tiers := map[string]string{}
+	for _, tier := range strings.Split(locality, ",") {
+		parts := strings.SplitN(tier, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		tiers[parts[0]] = parts[1]
+	}
+
+	return tiers
+}
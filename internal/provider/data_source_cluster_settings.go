@@ -0,0 +1,208 @@
+package provider
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	clusterSettingsNamesAttr  = "names"
+	clusterSettingsPrefixAttr = "prefix"
+	clusterSettingsAttr       = "settings"
+	clusterSettingsValuesAttr = "values"
+
+	settingNameAttr         = "name"
+	settingValueAttr        = "value"
+	settingTypeAttr         = "type"
+	settingDefaultValueAttr = "default_value"
+	settingDescriptionAttr  = "description"
+	settingPublicAttr       = "public"
+)
+
+func dataSourceClusterSettings() *schema.Resource {
+	return &schema.Resource{
+		// This description is used by the documentation generator and the language server.
+		Description: "Data source used to read the cluster settings with `SHOW ALL CLUSTER SETTINGS`, without managing them.",
+
+		ReadContext: dataSourceClusterSettingsRead,
+
+		Schema: map[string]*schema.Schema{
+			clusterSettingsNamesAttr: {
+				Description: "Only read these settings, for example `kv.rangefeed.enabled`.",
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			clusterSettingsPrefixAttr: {
+				Description: "Only read the settings whose name starts with this prefix, for example `kv.`.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+			},
+			clusterSettingsAttr: {
+				Description: "Settings matching the filters, ordered by name.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						settingNameAttr: {
+							Description: "Name of the setting.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						settingValueAttr: {
+							Description: "Current value of the setting.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						settingTypeAttr: {
+							Description: "Type of the setting, for example `b` for booleans or `d` for durations.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						settingDefaultValueAttr: {
+							Description: "Default value of the setting, empty on clusters before v22.2.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						settingDescriptionAttr: {
+							Description: "Description of the setting.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						settingPublicAttr: {
+							Description: "True if the setting is documented.",
+							Type:        schema.TypeBool,
+							Computed:    true,
+						},
+					},
+				},
+			},
+			clusterSettingsValuesAttr: {
+				Description: "Current values of the settings matching the filters, keyed by name.",
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			argLocalPort: {
+				Description: "Local port to be used for port-forward. (default is 26274), use different port to avoid same port opening.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "26274",
+			},
+		},
+	}
+}
+
+func dataSourceClusterSettingsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	local_port := d.Get(argLocalPort).(string)
+	names := convertToString(d.Get(clusterSettingsNamesAttr).(*schema.Set).List())
+	prefix := d.Get(clusterSettingsPrefixAttr).(string)
+	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
+
+	// stopCh control the port forwarding lifecycle. When it gets closed the
+	// port forward will terminate
+	stopCh := make(chan struct{}, 1)
+	// readyCh communicate when the port forward is ready to get traffic
+	readyCh := make(chan struct{})
+
+	tryPortForwardIfNeeded(ctx, d, meta, stopCh, readyCh, local_port)
+
+	conn, err := pgx.Connect(ctx, dns)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := conn.Ping(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+
+	rows, err := conn.Query(ctx, `SELECT * FROM [SHOW ALL CLUSTER SETTINGS] ORDER BY variable`)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer rows.Close()
+
+	columns := []string{}
+	for _, field := range rows.FieldDescriptions() {
+		columns = append(columns, string(field.Name))
+	}
+
+	settings := []interface{}{}
+	values := map[string]interface{}{}
+	for rows.Next() {
+		row, err := rows.Values()
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		setting := settingFromRow(columns, row)
+		name := setting[settingNameAttr].(string)
+
+		if !strings.HasPrefix(name, prefix) || (len(names) > 0 && !contains(names, name)) {
+			continue
+		}
+
+		settings = append(settings, setting)
+		values[name] = setting[settingValueAttr]
+	}
+	if err := rows.Err(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(strconv.Itoa(schema.HashString(prefix + "/" + strings.Join(names, ","))))
+	if err := d.Set(clusterSettingsAttr, settings); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(clusterSettingsValuesAttr, values); err != nil {
+		return diag.FromErr(err)
+	}
+
+	close(stopCh)
+
+	return diag.Diagnostics{}
+}
+
+// settingFromRow converts a row of SHOW ALL CLUSTER SETTINGS, whose columns
+// depend on the version of the cluster.
+func settingFromRow(columns []string, values []interface{}) map[string]interface{} {
+	setting := map[string]interface{}{
+		settingNameAttr:         "",
+		settingValueAttr:        "",
+		settingTypeAttr:         "",
+		settingDefaultValueAttr: "",
+		settingDescriptionAttr:  "",
+		settingPublicAttr:       false,
+	}
+
+	attrs := map[string]string{
+		"variable":      settingNameAttr,
+		"value":         settingValueAttr,
+		"setting_type":  settingTypeAttr,
+		"default_value": settingDefaultValueAttr,
+		"description":   settingDescriptionAttr,
+	}
+
+	for i, column := range columns {
+		if attr, ok := attrs[column]; ok {
+			if value, ok := values[i].(string); ok {
+				setting[attr] = value
+			}
+		}
+		if column == "public" {
+			if value, ok := values[i].(bool); ok {
+				setting[settingPublicAttr] = value
+			}
+		}
+	}
+
+	return setting
+}
@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceClusterSettings(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceClusterSettings,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"data.cockroach_cluster_settings.foo", "settings.#", "1"),
+					resource.TestCheckResourceAttr(
+						"data.cockroach_cluster_settings.foo", "settings.0.type", "b"),
+					resource.TestCheckResourceAttrSet(
+						"data.cockroach_cluster_settings.foo", "values.kv.rangefeed.enabled"),
+				),
+			},
+		},
+	})
+}
+
+const testAccDataSourceClusterSettings = `
+data "cockroach_cluster_settings" "foo" {
+  names = ["kv.rangefeed.enabled"]
+}
+`
+
+func TestSettingFromRow(t *testing.T) {
+	setting := settingFromRow(
+		[]string{"variable", "value", "setting_type", "public", "description", "default_value"},
+		[]interface{}{"kv.rangefeed.enabled", "true", "b", true, "if set, rangefeed registration is enabled", "false"},
+	)
+
+	expected := map[string]interface{}{
+		settingNameAttr:         "kv.rangefeed.enabled",
+		settingValueAttr:        "true",
+		settingTypeAttr:         "b",
+		settingDefaultValueAttr: "false",
+		settingDescriptionAttr:  "if set, rangefeed registration is enabled",
+		settingPublicAttr:       true,
+	}
+	if !reflect.DeepEqual(setting, expected) {
+		t.Errorf("expected %v, got %v", expected, setting)
+	}
+
+	// clusters before 22.2 have no default_value column
+	setting = settingFromRow([]string{"variable", "value"}, []interface{}{"version", "22.1"})
+	if setting[settingDefaultValueAttr] != "" || setting[settingValueAttr] != "22.1" {
+		t.Errorf("unexpected setting %v", setting)
+	}
+}
@@ -0,0 +1,39 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceCluster(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceCluster,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"data.cockroach_cluster.foo", "node_count", "1"),
+				),
+			},
+		},
+	})
+}
+
+const testAccDataSourceCluster = `
+data "cockroach_cluster" "foo" {}
+`
+
+func TestParseLocality(t *testing.T) {
+	expected := map[string]string{"region": "us-east1", "zone": "us-east1-b"}
+	if tiers := parseLocality("region=us-east1,zone=us-east1-b"); !reflect.DeepEqual(tiers, expected) {
+		t.Errorf("expected %v, got %v", expected, tiers)
+	}
+
+	if tiers := parseLocality(""); len(tiers) != 0 {
+		t.Errorf("expected no tiers, got %v", tiers)
+	}
+}
@@ -2,18 +2,35 @@ package provider
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"strconv"
 	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/jackc/pgx/v4"
+	"github.com/lib/pq"
 )
 
+const (
+	dbSchemasAttr    = "schemas"
+	dbTablesAttr     = "tables"
+	dbZoneConfigAttr = "zone_config"
+	dbGrantsAttr     = "grants"
+
+	grantGranteeAttr    = "grantee"
+	grantPrivilegesAttr = "privileges"
+)
+
+// schemas every database has, they are left out of the schemas and tables
+// of the data source
+var virtualSchemas = []string{"crdb_internal", "information_schema", "pg_catalog", "pg_extension"}
+
 func dataSourceDatabase() *schema.Resource {
 	return &schema.Resource{
 		// This description is used by the documentation generator and the language server.
-		Description: "Sample data source in the Terraform provider scaffolding.",
+		Description: "Data source used to read an existing database of the Cockroachdb cluster, with its regions, schemas, tables, zone configuration and grants.",
 
 		ReadContext: dataSourceDatabaseRead,
 
@@ -30,6 +47,75 @@ func dataSourceDatabase() *schema.Resource {
 				Optional:    true,
 				Computed:    true,
 			},
+			dbEncodingAttr: {
+				Description: "Encoding of the database.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			dbPrimaryRegionAttr: {
+				Description: "Primary region of a multi-region database.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			dbRegionsAttr: {
+				Description: "All the regions of a multi-region database, including the primary region.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			dbSecondaryRegionAttr: {
+				Description: "Secondary region of a multi-region database.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			dbSurvivalGoalAttr: {
+				Description: "Survival goal of a multi-region database, `zone` or `region`.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			dbPlacementAttr: {
+				Description: "Placement policy of a multi-region database, `default` or `restricted`.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			dbSchemasAttr: {
+				Description: "User defined schemas of the database, including `public`.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			dbTablesAttr: {
+				Description: "Tables of the database, as `<schema>.<table>`.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			dbZoneConfigAttr: {
+				Description: "Zone configuration in effect for the database, such as `num_replicas` or `gc.ttlseconds`, including the values inherited from the default zone.",
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			dbGrantsAttr: {
+				Description: "Privileges granted on the database.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						grantGranteeAttr: {
+							Description: "Role the privileges are granted to.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						grantPrivilegesAttr: {
+							Description: "Privileges of the role, such as `CONNECT` or `ALL`.",
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
 			argLocalPort: {
 				Description: "Local port to be used for port-forward. (default is 26259), use different port to avoid same port opening.",
 				Type:        schema.TypeString,
@@ -56,21 +142,104 @@ func dataSourceDatabaseRead(ctx context.Context, d *schema.ResourceData, meta in
 
 	conn, err := pgx.Connect(ctx, dns)
 
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	if err := conn.Ping(ctx); err != nil {
 		return diag.FromErr(err)
 	}
 	name := d.Get("name").(string)
 	var (
-		id    int
-		owner string
+		id               int
+		owner            string
+		encoding         string
+		primary_region   sql.NullString
+		regions          []string
+		secondary_region sql.NullString
+		survival_goal    sql.NullString
+		placement        sql.NullString
 	)
-	err = conn.QueryRow(ctx, `SELECT id, owner FROM crdb_internal.databases WHERE name = $1`, name).Scan(
+	err = conn.QueryRow(ctx,
+		`SELECT d.id, d.owner, pg_encoding_to_char(p.encoding), d.primary_region, d.regions, d.survival_goal
+		FROM crdb_internal.databases AS d
+		JOIN pg_catalog.pg_database AS p ON p.datname = d.name
+		WHERE d.name = $1`,
+		name,
+	).Scan(
 		&id,
 		&owner,
+		&encoding,
+		&primary_region,
+		&regions,
+		&survival_goal,
+	)
+	if err == pgx.ErrNoRows {
+		return diag.Diagnostics{
+			{
+				Severity: diag.Error,
+				Summary:  "Database not found",
+				Detail:   fmt.Sprintf("Database %s does not exist in the cluster.", name),
+			},
+		}
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// secondary regions and placement policies only exist for multi-region
+	// databases of clusters running v22.1 or later
+	if primary_region.String != "" {
+		multi_region, err := clusterVersionAtLeast(ctx, conn, "22.1")
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		if multi_region {
+			err = conn.QueryRow(ctx,
+				`SELECT secondary_region, placement_policy FROM crdb_internal.databases WHERE id = $1`,
+				id,
+			).Scan(&secondary_region, &placement)
+			if err != nil {
+				return diag.FromErr(err)
+			}
+		}
+	}
+
+	if regions == nil {
+		regions = []string{}
+	}
+
+	quoted_name := pq.QuoteIdentifier(name)
+
+	schemas, err := queryStrings(ctx, conn,
+		`SELECT schema_name FROM `+quoted_name+`.information_schema.schemata WHERE schema_name != ALL ($1) ORDER BY schema_name`,
+		virtualSchemas,
 	)
 	if err != nil {
 		return diag.FromErr(err)
 	}
+
+	tables, err := queryStrings(ctx, conn,
+		`SELECT table_schema || '.' || table_name FROM `+quoted_name+`.information_schema.tables
+		WHERE table_type = 'BASE TABLE' AND table_schema != ALL ($1) ORDER BY table_schema, table_name`,
+		virtualSchemas,
+	)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var zone_config_sql string
+	err = conn.QueryRow(ctx, `SELECT raw_config_sql FROM [SHOW ZONE CONFIGURATION FROM DATABASE `+quoted_name+`]`).Scan(&zone_config_sql)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	grants, err := readDatabaseGrants(ctx, conn, name)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	d.SetId(strconv.Itoa(id))
 	if err := d.Set(dbNameAttr, name); err != nil {
 		return diag.FromErr(err)
@@ -78,8 +247,118 @@ func dataSourceDatabaseRead(ctx context.Context, d *schema.ResourceData, meta in
 	if err := d.Set(dbOwnerAttr, owner); err != nil {
 		return diag.FromErr(err)
 	}
+	if err := d.Set(dbEncodingAttr, encoding); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(dbPrimaryRegionAttr, primary_region.String); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(dbRegionsAttr, regions); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(dbSecondaryRegionAttr, secondary_region.String); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(dbSurvivalGoalAttr, strings.ToLower(survival_goal.String)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(dbPlacementAttr, strings.ToLower(placement.String)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(dbSchemasAttr, schemas); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(dbTablesAttr, tables); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(dbZoneConfigAttr, parseZoneConfigSQL(zone_config_sql)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(dbGrantsAttr, grants); err != nil {
+		return diag.FromErr(err)
+	}
 
 	close(stopCh)
 
 	return diag.Diagnostics{}
 }
+
+// readDatabaseGrants returns the privileges on a database grouped by grantee.
+func readDatabaseGrants(ctx context.Context, conn *pgx.Conn, name string) ([]interface{}, error) {
+	rows, err := conn.Query(ctx,
+		`SELECT grantee, array_agg(privilege_type ORDER BY privilege_type)
+		FROM [SHOW GRANTS ON DATABASE `+pq.QuoteIdentifier(name)+`]
+		GROUP BY grantee ORDER BY grantee`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants := []interface{}{}
+	for rows.Next() {
+		var (
+			grantee    string
+			privileges []string
+		)
+		if err := rows.Scan(&grantee, &privileges); err != nil {
+			return nil, err
+		}
+
+		grants = append(grants, map[string]interface{}{
+			grantGranteeAttr:    grantee,
+			grantPrivilegesAttr: privileges,
+		})
+	}
+
+	return grants, rows.Err()
+}
+
+// queryStrings returns the single string column of a query.
+func queryStrings(ctx context.Context, conn *pgx.Conn, query string, args ...interface{}) ([]string, error) {
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
+
+// parseZoneConfigSQL reads the variables of the CONFIGURE ZONE USING statement
+// returned by SHOW ZONE CONFIGURATION, string values are unquoted.
+func parseZoneConfigSQL(statement string) map[string]string {
+	config := map[string]string{}
+
+	i := strings.Index(strings.ToUpper(statement), " USING")
+	if i < 0 {
+		return config
+	}
+
+	for _, line := range strings.Split(statement[i+len(" USING"):], "\n") {
+		line = strings.TrimSuffix(strings.TrimSpace(line), ",")
+
+		parts := strings.SplitN(line, " = ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		value := parts[1]
+		if len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+			value = strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+		}
+
+		config[strings.TrimSpace(parts[0])] = value
+	}
+
+	return config
+}
@@ -0,0 +1,285 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/jackc/pgx/v4"
+	"github.com/lib/pq"
+)
+
+const (
+	verificationScheduleIdAttr  = "schedule_id"
+	verificationBackupPathAttr  = "backup_path"
+	verificationDbNameAttr      = "database_name"
+	verificationTestRestoreAttr = "test_restore"
+	verificationScratchDbAttr   = "scratch_database"
+	verificationBackupAttr      = "backup"
+	verificationEndTimeAttr     = "end_time"
+	verificationVerifiedAttr    = "verified"
+)
+
+var (
+	backupStatementDbRegexp   = regexp.MustCompile(`(?i)\bBACKUP\s+DATABASE\s+("(?:[^"]|"")+"|[^\s,]+)`)
+	backupStatementPathRegexp = regexp.MustCompile(`(?i)\sINTO\s+(?:LATEST\s+IN\s+)?'((?:[^']|'')*)'`)
+)
+
+func dataSourceDatabaseBackupVerification() *schema.Resource {
+	return &schema.Resource{
+		// This description is used by the documentation generator and the language server.
+		Description: "Data source used to verify that the latest backup taken by a `cockroach_database_backup` schedule is complete and restorable. " +
+			"The plan fails with a detailed diagnostic when backup files are missing or corrupt.",
+
+		ReadContext: dataSourceDatabaseBackupVerificationRead,
+
+		Schema: map[string]*schema.Schema{
+			verificationScheduleIdAttr: {
+				Description: "ID of the backup schedule, usually `cockroach_database_backup.<name>.id`.",
+				Type:        schema.TypeString,
+				Required:    true,
+			},
+			verificationBackupPathAttr: {
+				Description: "Collection URI the schedule backs up into. Read from the schedule when not specified.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+			},
+			verificationDbNameAttr: {
+				Description: "Name of the backed up database. Read from the schedule when not specified.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+			},
+			verificationTestRestoreAttr: {
+				Description: "If true, the latest backup is also restored into a scratch database which is dropped afterwards.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			verificationScratchDbAttr: {
+				Description: "Name of the scratch database used for the test restore. (default is `<database_name>_restore_check`), the database must not exist.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+			},
+			verificationBackupAttr: {
+				Description: "Subdirectory of the latest backup inside the collection.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			verificationEndTimeAttr: {
+				Description: "End time of the latest backup.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			verificationVerifiedAttr: {
+				Description: "True once all the checks passed.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			argLocalPort: {
+				Description: "Local port to be used for port-forward. (default is 26261), use different port to avoid same port opening.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "26261",
+			},
+		},
+	}
+}
+
+func dataSourceDatabaseBackupVerificationRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	local_port := d.Get(argLocalPort).(string)
+	schedule_id := d.Get(verificationScheduleIdAttr).(string)
+	backup_path := d.Get(verificationBackupPathAttr).(string)
+	db_name := d.Get(verificationDbNameAttr).(string)
+	test_restore := d.Get(verificationTestRestoreAttr).(bool)
+	scratch_db := d.Get(verificationScratchDbAttr).(string)
+	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
+
+	// stopCh control the port forwarding lifecycle. When it gets closed the
+	// port forward will terminate
+	stopCh := make(chan struct{}, 1)
+	// readyCh communicate when the port forward is ready to get traffic
+	readyCh := make(chan struct{})
+
+	// SHOW SCHEDULE does not accept placeholders, make sure the id is a number
+	if _, err := strconv.ParseInt(schedule_id, 10, 64); err != nil {
+		return diag.Errorf("schedule_id must be a number, got: %s", schedule_id)
+	}
+
+	tryPortForwardIfNeeded(ctx, d, meta, stopCh, readyCh, local_port)
+
+	conn, err := pgx.Connect(ctx, dns)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := conn.Ping(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+
+	var statement sql.NullString
+	err = conn.QueryRow(ctx,
+		`SELECT command->>'backup_statement' FROM [SHOW SCHEDULE `+schedule_id+`]`,
+	).Scan(&statement)
+	if err == pgx.ErrNoRows {
+		return diag.Errorf("Cannot find backup schedule with id: %s", schedule_id)
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if backup_path == "" || db_name == "" {
+		if !statement.Valid {
+			return diag.Errorf("schedule %s is not a backup schedule", schedule_id)
+		}
+
+		stmt_db, stmt_path, err := parseBackupStatement(statement.String)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		if backup_path == "" {
+			backup_path = stmt_path
+		}
+
+		if db_name == "" {
+			db_name = stmt_db
+		}
+	}
+
+	var backups []string
+	rows, err := conn.Query(ctx, `SELECT path FROM [SHOW BACKUPS IN `+pq.QuoteLiteral(backup_path)+`]`)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return diag.FromErr(err)
+		}
+		backups = append(backups, path)
+	}
+	if err := rows.Err(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if len(backups) == 0 {
+		return diag.Errorf("no backups found in collection %s of schedule %s", backup_path, schedule_id)
+	}
+
+	// SHOW BACKUPS lists the subdirectories in chronological order
+	latest := backups[len(backups)-1]
+
+	var end_time sql.NullString
+	err = conn.QueryRow(ctx,
+		`SELECT max(end_time)::STRING FROM [SHOW BACKUP `+
+			pq.QuoteLiteral(latest)+
+			` IN `+
+			pq.QuoteLiteral(backup_path)+
+			` WITH check_files]`,
+	).Scan(&end_time)
+	if err != nil {
+		return diag.Diagnostics{
+			{
+				Severity: diag.Error,
+				Summary:  "Backup verification failed",
+				Detail: fmt.Sprintf("Checking the files of backup %s in %s (schedule %s) failed, files are missing or corrupt: %s",
+					latest, backup_path, schedule_id, err),
+			},
+		}
+	}
+
+	if test_restore {
+		if scratch_db == "" {
+			scratch_db = db_name + "_restore_check"
+		}
+
+		var exists bool
+		err = conn.QueryRow(ctx, `SELECT count(*) > 0 FROM crdb_internal.databases WHERE name = $1`, scratch_db).Scan(&exists)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		if exists {
+			return diag.Errorf("scratch database %s already exists, refusing to restore into it", scratch_db)
+		}
+
+		_, err = conn.Exec(ctx,
+			`RESTORE DATABASE `+
+				pq.QuoteIdentifier(db_name)+
+				` FROM `+
+				pq.QuoteLiteral(latest)+
+				` IN `+
+				pq.QuoteLiteral(backup_path)+
+				` WITH new_db_name = `+
+				pq.QuoteLiteral(scratch_db),
+		)
+		if err != nil {
+			// a failed restore can leave an offline database behind
+			conn.Exec(ctx, `DROP DATABASE IF EXISTS `+pq.QuoteIdentifier(scratch_db)+` CASCADE`)
+
+			return diag.Diagnostics{
+				{
+					Severity: diag.Error,
+					Summary:  "Backup test restore failed",
+					Detail: fmt.Sprintf("Restoring database %s from backup %s in %s (schedule %s) into %s failed: %s",
+						db_name, latest, backup_path, schedule_id, scratch_db, err),
+				},
+			}
+		}
+
+		_, err = conn.Exec(ctx, `DROP DATABASE `+pq.QuoteIdentifier(scratch_db)+` CASCADE`)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	d.SetId(schedule_id + "/" + latest)
+	if err := d.Set(verificationBackupPathAttr, backup_path); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(verificationDbNameAttr, db_name); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(verificationBackupAttr, latest); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(verificationEndTimeAttr, end_time.String); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(verificationVerifiedAttr, true); err != nil {
+		return diag.FromErr(err)
+	}
+
+	close(stopCh)
+
+	return diag.Diagnostics{}
+}
+
+// parseBackupStatement extracts the database name and the collection URI from
+// the backup statement stored in a backup schedule.
+func parseBackupStatement(statement string) (string, string, error) {
+	db_match := backupStatementDbRegexp.FindStringSubmatch(statement)
+	if db_match == nil {
+		return "", "", fmt.Errorf("cannot find the database in backup statement: %s", statement)
+	}
+
+	path_match := backupStatementPathRegexp.FindStringSubmatch(statement)
+	if path_match == nil {
+		return "", "", fmt.Errorf("cannot find the backup path in backup statement: %s", statement)
+	}
+
+	return unquoteIdentifier(db_match[1]), strings.ReplaceAll(path_match[1], `''`, `'`), nil
+}
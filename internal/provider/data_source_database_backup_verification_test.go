@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceDatabaseBackupVerification(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceDatabaseBackupVerification,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestMatchResourceAttr(
+						"data.cockroach_database_backup_verification.foo", "database_name", regexp.MustCompile("^test$")),
+					resource.TestCheckResourceAttr(
+						"data.cockroach_database_backup_verification.foo", "verified", "true"),
+				),
+			},
+		},
+	})
+}
+
+func TestParseBackupStatement(t *testing.T) {
+	cases := []struct {
+		statement string
+		database  string
+		path      string
+	}{
+		{
+			statement: `BACKUP DATABASE test INTO 'nodelocal://1/test' WITH detached`,
+			database:  "test",
+			path:      "nodelocal://1/test",
+		},
+		{
+			statement: `BACKUP DATABASE "my ""db""" INTO LATEST IN 's3://bucket/it''s?AUTH=implicit' WITH detached`,
+			database:  `my "db"`,
+			path:      "s3://bucket/it's?AUTH=implicit",
+		},
+	}
+
+	for _, c := range cases {
+		database, path, err := parseBackupStatement(c.statement)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %s", c.statement, err)
+		}
+		if database != c.database {
+			t.Errorf("expected database %q, got %q", c.database, database)
+		}
+		if path != c.path {
+			t.Errorf("expected path %q, got %q", c.path, path)
+		}
+	}
+
+	if _, _, err := parseBackupStatement(`BACKUP TABLE foo INTO 'nodelocal://1/foo'`); err == nil {
+		t.Error("expected an error for a table backup statement")
+	}
+}
+
+const testAccDataSourceDatabaseBackupVerification = `
+resource "cockroach_database_backup" "foo" {
+  name = "scheduller"
+  backup_path = "nodelocal://1/test"
+  database_name = "test"
+  local_port = "23455"
+}
+
+data "cockroach_database_backup_verification" "foo" {
+  schedule_id = cockroach_database_backup.foo.id
+  test_restore = true
+  local_port = "23456"
+}
+`
@@ -1,6 +1,7 @@
 package provider
 
 import (
+	"reflect"
 	"regexp"
 	"testing"
 
@@ -30,3 +31,30 @@ data "cockroach_database" "foo" {
   name = "bar"
 }
 `
+
+func TestParseZoneConfigSQL(t *testing.T) {
+	statement := `ALTER DATABASE bar CONFIGURE ZONE USING
+	range_min_bytes = 134217728,
+	range_max_bytes = 536870912,
+	gc.ttlseconds = 90000,
+	num_replicas = 3,
+	constraints = '{+region=us-east1: 1}',
+	lease_preferences = '[[+region=us-east1]]'`
+
+	expected := map[string]string{
+		"range_min_bytes":   "134217728",
+		"range_max_bytes":   "536870912",
+		"gc.ttlseconds":     "90000",
+		"num_replicas":      "3",
+		"constraints":       "{+region=us-east1: 1}",
+		"lease_preferences": "[[+region=us-east1]]",
+	}
+
+	if config := parseZoneConfigSQL(statement); !reflect.DeepEqual(config, expected) {
+		t.Errorf("expected %v, got %v", expected, config)
+	}
+
+	if config := parseZoneConfigSQL(""); len(config) != 0 {
+		t.Errorf("expected an empty configuration, got %v", config)
+	}
+}
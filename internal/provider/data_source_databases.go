@@ -0,0 +1,252 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/jackc/pgx/v4"
+	"github.com/lib/pq"
+)
+
+const (
+	databasesNameRegexAttr     = "name_regex"
+	databasesOwnerAttr         = "owner"
+	databasesIncludeSystemAttr = "include_system"
+	databasesIncludeSizeAttr   = "include_size"
+	databasesAttr              = "databases"
+
+	databasesIdAttr            = "id"
+	databasesDatabaseNameAttr  = "name"
+	databasesDatabaseOwnerAttr = "owner"
+	databasesPrimaryRegionAttr = "primary_region"
+	databasesRegionsAttr       = "regions"
+	databasesSurvivalGoalAttr  = "survival_goal"
+	databasesPlacementAttr     = "placement"
+	databasesSizeAttr          = "approximate_size_bytes"
+)
+
+func dataSourceDatabases() *schema.Resource {
+	return &schema.Resource{
+		// This description is used by the documentation generator and the language server.
+		Description: "Data source used to list the databases of the cluster with their metadata.",
+
+		ReadContext: dataSourceDatabasesRead,
+
+		Schema: map[string]*schema.Schema{
+			databasesNameRegexAttr: {
+				Description:  "Only list the databases whose name matches this regular expression.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+			databasesOwnerAttr: {
+				Description: "Only list the databases owned by this role.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+			},
+			databasesIncludeSystemAttr: {
+				Description: "List the `system` database as well.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			databasesIncludeSizeAttr: {
+				Description: "Compute `approximate_size_bytes` of the listed databases, which scans the ranges of every listed database.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			databasesAttr: {
+				Description: "Databases matching the filters, ordered by name.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						databasesIdAttr: {
+							Description: "ID of the database.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						databasesDatabaseNameAttr: {
+							Description: "Name of the database.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						databasesDatabaseOwnerAttr: {
+							Description: "Owner of the database.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						databasesPrimaryRegionAttr: {
+							Description: "Primary region of a multi-region database.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						databasesRegionsAttr: {
+							Description: "All the regions of a multi-region database, including the primary region.",
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						databasesSurvivalGoalAttr: {
+							Description: "Survival goal of a multi-region database, `zone` or `region`.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						databasesPlacementAttr: {
+							Description: "Placement policy of a multi-region database, `default` or `restricted`.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						databasesSizeAttr: {
+							Description: "Approximate size of the ranges holding the database, `0` unless `include_size` is set. From v23.1 on, adjacent databases can share a range, so this is an upper bound of the size of the database.",
+							Type:        schema.TypeInt,
+							Computed:    true,
+						},
+					},
+				},
+			},
+			argLocalPort: {
+				Description: "Local port to be used for port-forward. (default is 26265), use different port to avoid same port opening.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "26265",
+			},
+		},
+	}
+}
+
+func dataSourceDatabasesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	local_port := d.Get(argLocalPort).(string)
+	name_regex := d.Get(databasesNameRegexAttr).(string)
+	owner := d.Get(databasesOwnerAttr).(string)
+	include_system := d.Get(databasesIncludeSystemAttr).(bool)
+	include_size := d.Get(databasesIncludeSizeAttr).(bool)
+	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
+
+	name_filter, err := regexp.Compile(name_regex)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// stopCh control the port forwarding lifecycle. When it gets closed the
+	// port forward will terminate
+	stopCh := make(chan struct{}, 1)
+	// readyCh communicate when the port forward is ready to get traffic
+	readyCh := make(chan struct{})
+
+	tryPortForwardIfNeeded(ctx, d, meta, stopCh, readyCh, local_port)
+
+	conn, err := pgx.Connect(ctx, dns)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := conn.Ping(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// placement policies only exist on clusters running v22.1 or later
+	placement_supported, err := clusterVersionAtLeast(ctx, conn, "22.1")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	placement_column := "NULL::STRING"
+	if placement_supported {
+		placement_column = "d.placement_policy"
+	}
+
+	rows, err := conn.Query(ctx,
+		`SELECT d.id, d.name, d.owner, d.primary_region, d.regions, d.survival_goal, `+placement_column+`
+		FROM crdb_internal.databases AS d
+		WHERE ($1 = '' OR d.owner = $1) AND ($2 OR d.name != 'system')
+		ORDER BY d.name`,
+		owner, include_system,
+	)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer rows.Close()
+
+	databases := []interface{}{}
+	ids := []string{}
+	for rows.Next() {
+		var (
+			id             int64
+			name           string
+			db_owner       string
+			primary_region sql.NullString
+			regions        []string
+			survival_goal  sql.NullString
+			placement      sql.NullString
+		)
+		if err := rows.Scan(&id, &name, &db_owner, &primary_region, &regions, &survival_goal, &placement); err != nil {
+			return diag.FromErr(err)
+		}
+
+		if !name_filter.MatchString(name) {
+			continue
+		}
+
+		if regions == nil {
+			regions = []string{}
+		}
+
+		ids = append(ids, strconv.FormatInt(id, 10))
+		databases = append(databases, map[string]interface{}{
+			databasesIdAttr:            strconv.FormatInt(id, 10),
+			databasesDatabaseNameAttr:  name,
+			databasesDatabaseOwnerAttr: db_owner,
+			databasesPrimaryRegionAttr: primary_region.String,
+			databasesRegionsAttr:       regions,
+			databasesSurvivalGoalAttr:  strings.ToLower(survival_goal.String),
+			databasesPlacementAttr:     strings.ToLower(placement.String),
+			databasesSizeAttr:          0,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return diag.FromErr(err)
+	}
+	rows.Close()
+
+	// sizes are summed over the ranges of every matched database, which is
+	// expensive on large clusters
+	if include_size && len(databases) != 0 {
+		detailed_ranges, err := clusterVersionAtLeast(ctx, conn, "23.1")
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		for _, raw := range databases {
+			database := raw.(map[string]interface{})
+			name := database[databasesDatabaseNameAttr].(string)
+			_, size, err := readRangeStats(ctx, conn, "DATABASE "+pq.QuoteIdentifier(name), detailed_ranges)
+			if err != nil {
+				return diag.FromErr(err)
+			}
+			database[databasesSizeAttr] = int(size)
+		}
+	}
+
+	// the id changes whenever the set of databases changes
+	d.SetId(strconv.Itoa(schema.HashString(strings.Join(ids, ","))))
+	if err := d.Set(databasesAttr, databases); err != nil {
+		return diag.FromErr(err)
+	}
+
+	close(stopCh)
+
+	return diag.Diagnostics{}
+}
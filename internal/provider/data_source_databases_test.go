@@ -0,0 +1,42 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceDatabases(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceDatabases,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"data.cockroach_databases.foo", "databases.0.name", "defaultdb"),
+				),
+			},
+		},
+	})
+}
+
+const testAccDataSourceDatabases = `
+data "cockroach_databases" "foo" {
+  name_regex = "^default"
+  owner      = "root"
+}
+`
+
+func TestRangeStatsStatement(t *testing.T) {
+	expected := `SELECT count(*), COALESCE(sum(range_size), 0)::INT8 FROM [SHOW RANGES FROM DATABASE "orders" WITH DETAILS]`
+	if statement := rangeStatsStatement(`DATABASE "orders"`, true); statement != expected {
+		t.Errorf("expected %s, got %s", expected, statement)
+	}
+
+	expected = `SELECT count(*), COALESCE(sum(range_size_mb) * 1000000, 0)::INT8 FROM [SHOW RANGES FROM TABLE "orders"."public"."items"]`
+	if statement := rangeStatsStatement(`TABLE "orders"."public"."items"`, false); statement != expected {
+		t.Errorf("expected %s, got %s", expected, statement)
+	}
+}
@@ -0,0 +1,298 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/jackc/pgx/v4"
+	"github.com/lib/pq"
+)
+
+const (
+	grantsObjectTypeAttr      = "object_type"
+	grantsDatabaseAttr        = "database"
+	grantsSchemaAttr          = "schema"
+	grantsObjectNameAttr      = "name"
+	grantsGranteeAttr         = "grantee"
+	grantsExpandInheritedAttr = "expand_inherited"
+	grantsAttr                = "grants"
+
+	grantObjectAttr    = "object"
+	grantPrivilegeAttr = "privilege"
+	grantGrantableAttr = "grantable"
+)
+
+func dataSourceGrants() *schema.Resource {
+	return &schema.Resource{
+		// This description is used by the documentation generator and the language server.
+		Description: "Data source used to read the privileges granted on a database, schema, table or type, or every privilege granted to a role, with `SHOW GRANTS`.",
+
+		ReadContext: dataSourceGrantsRead,
+
+		Schema: map[string]*schema.Schema{
+			grantsObjectTypeAttr: {
+				Description:  "Type of the object, `database`, `schema`, `table` or `type`. Without it, the privileges granted to `grantee` on every object are returned.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validation.StringInSlice([]string{"database", "schema", "table", "type"}, false),
+			},
+			grantsDatabaseAttr: {
+				Description: "Database of the object, or the database itself when `object_type` is `database`. Required with `object_type`, without it limits the schemas, tables and types to this database.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+			},
+			grantsSchemaAttr: {
+				Description: "Schema of the object, or the schema itself when `object_type` is `schema`. Not used for databases.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "public",
+			},
+			grantsObjectNameAttr: {
+				Description: "Name of the table or type. Required when `object_type` is `table` or `type`.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+			},
+			grantsGranteeAttr: {
+				Description: "Only return the privileges granted to this role. Required without `object_type`.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+			},
+			grantsExpandInheritedAttr: {
+				Description: "Also return the privileges `grantee` inherits from the roles it is a direct or indirect member of, and from `public`. Ignored without `grantee`.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			grantsAttr: {
+				Description: "Privileges granted on the object, or to `grantee` without `object_type`, one element per grantee, object and privilege.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						grantGranteeAttr: {
+							Description: "Role the privilege is granted to. With `expand_inherited`, the role `grantee` inherits it from.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						grantObjectAttr: {
+							Description: "Qualified name of the object, for example `db.public.table`.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						grantPrivilegeAttr: {
+							Description: "Privilege, such as `SELECT` or `ALL`.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						grantGrantableAttr: {
+							Description: "True if the grantee can grant the privilege to other roles.",
+							Type:        schema.TypeBool,
+							Computed:    true,
+						},
+					},
+				},
+			},
+			argLocalPort: {
+				Description: "Local port to be used for port-forward. (default is 26272), use different port to avoid same port opening.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "26272",
+			},
+		},
+	}
+}
+
+func dataSourceGrantsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	local_port := d.Get(argLocalPort).(string)
+	object_type := d.Get(grantsObjectTypeAttr).(string)
+	database := d.Get(grantsDatabaseAttr).(string)
+	schema_name := d.Get(grantsSchemaAttr).(string)
+	name := d.Get(grantsObjectNameAttr).(string)
+	grantee := d.Get(grantsGranteeAttr).(string)
+	expand_inherited := d.Get(grantsExpandInheritedAttr).(bool)
+	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
+
+	if object_type == "" && grantee == "" {
+		return diag.Errorf("%s is required when %s is not set", grantsGranteeAttr, grantsObjectTypeAttr)
+	}
+
+	object, err := grantsObject(object_type, database, schema_name, name)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// stopCh control the port forwarding lifecycle. When it gets closed the
+	// port forward will terminate
+	stopCh := make(chan struct{}, 1)
+	// readyCh communicate when the port forward is ready to get traffic
+	readyCh := make(chan struct{})
+
+	tryPortForwardIfNeeded(ctx, d, meta, stopCh, readyCh, local_port)
+
+	conn, err := pgx.Connect(ctx, dns)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := conn.Ping(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// SHOW GRANTS FOR a role lists the schemas, tables and types of the
+	// current database only
+	if object_type == "" && database != "" {
+		if _, err := conn.Exec(ctx, "SET database = "+pq.QuoteIdentifier(database)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	grantees := []string{}
+	if grantee != "" {
+		grantees = append(grantees, grantee)
+	}
+
+	if grantee != "" && expand_inherited {
+		inherited, err := queryStrings(ctx, conn,
+			`WITH RECURSIVE memberships (role) AS (
+				SELECT role FROM system.role_members WHERE member = $1
+				UNION
+				SELECT m.role FROM system.role_members AS m JOIN memberships ON m.member = memberships.role
+			)
+			SELECT role FROM memberships ORDER BY role`,
+			grantee,
+		)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		// every role is a member of public
+		grantees = append(grantees, inherited...)
+		if !contains(grantees, "public") {
+			grantees = append(grantees, "public")
+		}
+	}
+
+	statement := showGrantsStatement(object_type, object, grantees)
+
+	rows, err := conn.Query(ctx, statement)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer rows.Close()
+
+	columns := []string{}
+	for _, field := range rows.FieldDescriptions() {
+		columns = append(columns, string(field.Name))
+	}
+
+	grants := []interface{}{}
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		grants = append(grants, grantFromRow(columns, values))
+	}
+	if err := rows.Err(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(strconv.Itoa(schema.HashString(database + "/" + statement)))
+	if err := d.Set(grantsAttr, grants); err != nil {
+		return diag.FromErr(err)
+	}
+
+	close(stopCh)
+
+	return diag.Diagnostics{}
+}
+
+// grantsObject returns the quoted name of the object the grants are read on,
+// empty when no object type is given.
+func grantsObject(object_type string, database string, schema_name string, name string) (string, error) {
+	if object_type == "" {
+		return "", nil
+	}
+
+	if database == "" {
+		return "", fmt.Errorf("%s is required when %s is set", grantsDatabaseAttr, grantsObjectTypeAttr)
+	}
+
+	switch object_type {
+	case "database":
+		return pq.QuoteIdentifier(database), nil
+	case "schema":
+		return pq.QuoteIdentifier(database) + "." + pq.QuoteIdentifier(schema_name), nil
+	}
+
+	if name == "" {
+		return "", fmt.Errorf("%s is required when %s is %s", grantsObjectNameAttr, grantsObjectTypeAttr, object_type)
+	}
+
+	return pq.QuoteIdentifier(database) + "." + pq.QuoteIdentifier(schema_name) + "." + pq.QuoteIdentifier(name), nil
+}
+
+// showGrantsStatement builds the SHOW GRANTS statement on an object, for the
+// given grantees or for every role when there are none. Without an object
+// type, it lists the privileges of the grantees on every object.
+func showGrantsStatement(object_type string, object string, grantees []string) string {
+	statement := "SHOW GRANTS"
+	if object_type != "" {
+		statement += " ON " + strings.ToUpper(object_type) + " " + object
+	}
+	if len(grantees) > 0 {
+		statement += " FOR " + quoteIdentifiers(grantees)
+	}
+
+	return statement
+}
+
+// grantFromRow converts a row of SHOW GRANTS, whose columns depend on the
+// type of the object and on the version of the cluster. SHOW GRANTS FOR a role
+// names tables relation_name, or object_name from v23.1 on.
+func grantFromRow(columns []string, values []interface{}) map[string]interface{} {
+	grant := map[string]interface{}{
+		grantGranteeAttr:   "",
+		grantObjectAttr:    "",
+		grantPrivilegeAttr: "",
+		grantGrantableAttr: false,
+	}
+
+	object := []string{}
+	for i, column := range columns {
+		switch column {
+		case "database_name", "schema_name", "table_name", "type_name", "relation_name", "object_name":
+			if value, ok := values[i].(string); ok {
+				object = append(object, value)
+			}
+		case "grantee":
+			if value, ok := values[i].(string); ok {
+				grant[grantGranteeAttr] = value
+			}
+		case "privilege_type":
+			if value, ok := values[i].(string); ok {
+				grant[grantPrivilegeAttr] = value
+			}
+		case "is_grantable":
+			if value, ok := values[i].(bool); ok {
+				grant[grantGrantableAttr] = value
+			}
+		}
+	}
+	grant[grantObjectAttr] = strings.Join(object, ".")
+
+	return grant
+}
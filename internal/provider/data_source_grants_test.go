@@ -0,0 +1,106 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceGrants(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceGrants,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"data.cockroach_grants.foo", "grants.0.grantee", "admin"),
+					resource.TestCheckResourceAttr(
+						"data.cockroach_grants.foo", "grants.0.object", "defaultdb"),
+				),
+			},
+		},
+	})
+}
+
+const testAccDataSourceGrants = `
+data "cockroach_grants" "foo" {
+  object_type = "database"
+  database    = "defaultdb"
+  grantee     = "admin"
+}
+`
+
+func TestShowGrantsStatement(t *testing.T) {
+	object, err := grantsObject("table", "db", "public", "my table")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SHOW GRANTS ON TABLE "db"."public"."my table" FOR "app", "public"`
+	if statement := showGrantsStatement("table", object, []string{"app", "public"}); statement != expected {
+		t.Errorf("expected %s, got %s", expected, statement)
+	}
+
+	object, err = grantsObject("schema", "db", "app", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `SHOW GRANTS ON SCHEMA "db"."app"`
+	if statement := showGrantsStatement("schema", object, nil); statement != expected {
+		t.Errorf("expected %s, got %s", expected, statement)
+	}
+
+	if _, err := grantsObject("type", "db", "public", ""); err == nil {
+		t.Error("expected an error without the name of the type")
+	}
+
+	if _, err := grantsObject("table", "", "public", "accounts"); err == nil {
+		t.Error("expected an error without the database of the table")
+	}
+
+	object, err = grantsObject("", "", "public", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `SHOW GRANTS FOR "app"`
+	if statement := showGrantsStatement("", object, []string{"app"}); statement != expected {
+		t.Errorf("expected %s, got %s", expected, statement)
+	}
+}
+
+func TestGrantFromRow(t *testing.T) {
+	grant := grantFromRow(
+		[]string{"database_name", "schema_name", "table_name", "grantee", "privilege_type", "is_grantable"},
+		[]interface{}{"db", "public", "accounts", "app", "SELECT", true},
+	)
+
+	expected := map[string]interface{}{
+		grantGranteeAttr:   "app",
+		grantObjectAttr:    "db.public.accounts",
+		grantPrivilegeAttr: "SELECT",
+		grantGrantableAttr: true,
+	}
+	if !reflect.DeepEqual(grant, expected) {
+		t.Errorf("expected %v, got %v", expected, grant)
+	}
+
+	// clusters before 22.1 have no is_grantable column
+	grant = grantFromRow([]string{"database_name", "grantee", "privilege_type"}, []interface{}{"db", "app", "CONNECT"})
+	if grant[grantGrantableAttr] != false || grant[grantObjectAttr] != "db" {
+		t.Errorf("unexpected grant %v", grant)
+	}
+
+	// SHOW GRANTS FOR a role
+	grant = grantFromRow(
+		[]string{"database_name", "schema_name", "relation_name", "grantee", "privilege_type", "is_grantable"},
+		[]interface{}{"db", "public", "accounts", "app", "SELECT", false},
+	)
+	if grant[grantObjectAttr] != "db.public.accounts" {
+		t.Errorf("unexpected grant %v", grant)
+	}
+}
@@ -0,0 +1,345 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	jobsTypeAttr             = "type"
+	jobsStatusAttr           = "status"
+	jobsDescriptionRegexAttr = "description_regex"
+	jobsCreatedAfterAttr     = "created_after"
+	jobsCreatedBeforeAttr    = "created_before"
+	jobsAttr                 = "jobs"
+
+	jobIdAttr                = "id"
+	jobTypeAttr              = "type"
+	jobDescriptionAttr       = "description"
+	jobUserNameAttr          = "user_name"
+	jobStatusAttr            = "status"
+	jobFractionCompletedAttr = "fraction_completed"
+	jobErrorAttr             = "error"
+	jobCreatedAttr           = "created"
+	jobFinishedAttr          = "finished"
+	jobScheduleIdAttr        = "schedule_id"
+)
+
+var jobStatuses = []string{
+	"pending",
+	"running",
+	"paused",
+	"pause-requested",
+	"cancel-requested",
+	"canceled",
+	"reverting",
+	"revert-failed",
+	"failed",
+	"succeeded",
+}
+
+// jobsFilter holds the filters of the cockroach_jobs data source, empty
+// values match every job. The description is matched in Go, the other filters
+// are sent to the cluster.
+type jobsFilter struct {
+	job_type       string
+	status         string
+	description    *regexp.Regexp
+	created_after  time.Time
+	created_before time.Time
+}
+
+func dataSourceJobs() *schema.Resource {
+	return &schema.Resource{
+		// This description is used by the documentation generator and the language server.
+		Description: "Data source used to list the jobs of the cluster, such as backups, restores, schema changes and changefeeds. " +
+			"Jobs are read with `SHOW JOBS`, which returns the running jobs and the jobs finished in the last 12 hours.",
+
+		ReadContext: dataSourceJobsRead,
+
+		Schema: map[string]*schema.Schema{
+			jobsTypeAttr: {
+				Description: "Only list the jobs of this type, for example `BACKUP`, `RESTORE`, `SCHEMA CHANGE` or `CHANGEFEED`.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+			},
+			jobsStatusAttr: {
+				Description:  "Only list the jobs with this status, for example `running`, `failed` or `succeeded`.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validation.StringInSlice(append([]string{""}, jobStatuses...), false),
+			},
+			jobsDescriptionRegexAttr: {
+				Description:  "Only list the jobs whose description matches this regular expression.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+			jobsCreatedAfterAttr: {
+				Description:  "Only list the jobs created at or after this time, in RFC3339 format.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validation.Any(validation.StringIsEmpty, validation.IsRFC3339Time),
+			},
+			jobsCreatedBeforeAttr: {
+				Description:  "Only list the jobs created before this time, in RFC3339 format.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validation.Any(validation.StringIsEmpty, validation.IsRFC3339Time),
+			},
+			jobsAttr: {
+				Description: "Jobs matching the filters, ordered by creation time.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						jobIdAttr: {
+							Description: "ID of the job.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						jobTypeAttr: {
+							Description: "Type of the job.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						jobDescriptionAttr: {
+							Description: "Description of the job, usually the statement which created it.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						jobUserNameAttr: {
+							Description: "User who created the job.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						jobStatusAttr: {
+							Description: "Status of the job.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						jobFractionCompletedAttr: {
+							Description: "Progress of the job, between 0 and 1.",
+							Type:        schema.TypeFloat,
+							Computed:    true,
+						},
+						jobErrorAttr: {
+							Description: "Error of a failed job.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						jobCreatedAttr: {
+							Description: "Creation time of the job, in RFC3339 format.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						jobFinishedAttr: {
+							Description: "Time the job finished, in RFC3339 format, empty while the job runs.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						jobScheduleIdAttr: {
+							Description: "ID of the schedule which created the job, empty if the job was not created by a schedule.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+					},
+				},
+			},
+			argLocalPort: {
+				Description: "Local port to be used for port-forward. (default is 26271), use different port to avoid same port opening.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "26271",
+			},
+		},
+	}
+}
+
+func dataSourceJobsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	local_port := d.Get(argLocalPort).(string)
+	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
+
+	filter, err := expandJobsFilter(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// stopCh control the port forwarding lifecycle. When it gets closed the
+	// port forward will terminate
+	stopCh := make(chan struct{}, 1)
+	// readyCh communicate when the port forward is ready to get traffic
+	readyCh := make(chan struct{})
+
+	tryPortForwardIfNeeded(ctx, d, meta, stopCh, readyCh, local_port)
+
+	conn, err := pgx.Connect(ctx, dns)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := conn.Ping(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// crdb_internal.jobs shows the same jobs as SHOW JOBS to the user,
+	// unlike system.jobs which needs the admin role
+	where, args := filter.whereClause()
+	rows, err := conn.Query(ctx,
+		`SELECT j.job_id, j.job_type, j.description, j.user_name, j.status, j.fraction_completed, j.error, j.created, j.finished, c.created_by_id
+		FROM [SHOW JOBS] AS j
+		LEFT JOIN crdb_internal.jobs AS c ON c.job_id = j.job_id AND c.created_by_type = 'crdb_schedule'
+		`+where+`
+		ORDER BY j.created, j.job_id`,
+		args...,
+	)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer rows.Close()
+
+	jobs := []interface{}{}
+	ids := []string{}
+	for rows.Next() {
+		var (
+			id                 int64
+			job_type           string
+			description        string
+			user_name          string
+			status             string
+			fraction_completed sql.NullFloat64
+			job_error          sql.NullString
+			created            time.Time
+			finished           *time.Time
+			schedule_id        sql.NullInt64
+		)
+		if err := rows.Scan(&id, &job_type, &description, &user_name, &status, &fraction_completed, &job_error, &created, &finished, &schedule_id); err != nil {
+			return diag.FromErr(err)
+		}
+
+		if filter.description != nil && !filter.description.MatchString(description) {
+			continue
+		}
+
+		finished_at := ""
+		if finished != nil {
+			finished_at = finished.UTC().Format(time.RFC3339)
+		}
+
+		schedule := ""
+		if schedule_id.Valid {
+			schedule = strconv.FormatInt(schedule_id.Int64, 10)
+		}
+
+		ids = append(ids, strconv.FormatInt(id, 10))
+		jobs = append(jobs, map[string]interface{}{
+			jobIdAttr:                strconv.FormatInt(id, 10),
+			jobTypeAttr:              job_type,
+			jobDescriptionAttr:       description,
+			jobUserNameAttr:          user_name,
+			jobStatusAttr:            status,
+			jobFractionCompletedAttr: fraction_completed.Float64,
+			jobErrorAttr:             job_error.String,
+			jobCreatedAttr:           created.UTC().Format(time.RFC3339),
+			jobFinishedAttr:          finished_at,
+			jobScheduleIdAttr:        schedule,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// the id changes whenever the set of jobs changes
+	d.SetId(strconv.Itoa(schema.HashString(strings.Join(ids, ","))))
+	if err := d.Set(jobsAttr, jobs); err != nil {
+		return diag.FromErr(err)
+	}
+
+	close(stopCh)
+
+	return diag.Diagnostics{}
+}
+
+// expandJobsFilter reads the filters of the cockroach_jobs data source.
+func expandJobsFilter(d *schema.ResourceData) (jobsFilter, error) {
+	filter := jobsFilter{
+		job_type: d.Get(jobsTypeAttr).(string),
+		status:   d.Get(jobsStatusAttr).(string),
+	}
+
+	if description_regex := d.Get(jobsDescriptionRegexAttr).(string); description_regex != "" {
+		description, err := regexp.Compile(description_regex)
+		if err != nil {
+			return filter, err
+		}
+		filter.description = description
+	}
+
+	var err error
+	if created_after := d.Get(jobsCreatedAfterAttr).(string); created_after != "" {
+		if filter.created_after, err = time.Parse(time.RFC3339, created_after); err != nil {
+			return filter, err
+		}
+	}
+
+	if created_before := d.Get(jobsCreatedBeforeAttr).(string); created_before != "" {
+		if filter.created_before, err = time.Parse(time.RFC3339, created_before); err != nil {
+			return filter, err
+		}
+	}
+
+	return filter, nil
+}
+
+// whereClause builds the WHERE clause of the type, status and creation time
+// filters over SHOW JOBS aliased as j, job types are compared ignoring the
+// case. SHOW JOBS reports creation times in UTC.
+func (f jobsFilter) whereClause() (string, []interface{}) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if f.job_type != "" {
+		add("j.job_type = upper($%d)", f.job_type)
+	}
+
+	if f.status != "" {
+		add("j.status = $%d", f.status)
+	}
+
+	if !f.created_after.IsZero() {
+		add("j.created >= $%d", f.created_after.UTC())
+	}
+
+	if !f.created_before.IsZero() {
+		add("j.created < $%d", f.created_before.UTC())
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,65 @@
+package provider
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceJobs(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceJobs,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"data.cockroach_jobs.foo", "jobs.#", "0"),
+				),
+			},
+		},
+	})
+}
+
+const testAccDataSourceJobs = `
+data "cockroach_jobs" "foo" {
+  type   = "backup"
+  status = "failed"
+}
+`
+
+func TestJobsFilterWhereClause(t *testing.T) {
+	created := time.Date(2022, 10, 1, 10, 0, 0, 0, time.UTC)
+
+	if where, args := (jobsFilter{}).whereClause(); where != "" || len(args) != 0 {
+		t.Errorf("expected an empty filter to match every job, got %s %v", where, args)
+	}
+
+	filter := jobsFilter{
+		job_type:       "backup",
+		status:         "succeeded",
+		description:    regexp.MustCompile(`s3://bucket`),
+		created_after:  created.Add(-time.Hour),
+		created_before: created.Add(time.Hour),
+	}
+
+	where, args := filter.whereClause()
+	expected := "WHERE j.job_type = upper($1) AND j.status = $2 AND j.created >= $3 AND j.created < $4"
+	if where != expected {
+		t.Errorf("expected %s, got %s", expected, where)
+	}
+
+	expected_args := []interface{}{"backup", "succeeded", created.Add(-time.Hour), created.Add(time.Hour)}
+	if !reflect.DeepEqual(args, expected_args) {
+		t.Errorf("expected %v, got %v", expected_args, args)
+	}
+
+	where, args = (jobsFilter{status: "failed"}).whereClause()
+	if where != "WHERE j.status = $1" || !reflect.DeepEqual(args, []interface{}{"failed"}) {
+		t.Errorf("unexpected clause %s %v", where, args)
+	}
+}
@@ -0,0 +1,237 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/jackc/pgx/v4"
+	"github.com/lib/pq"
+)
+
+const (
+	regionsDatabaseAttr        = "database"
+	regionsAttr                = "regions"
+	regionsNamesAttr           = "names"
+	regionsNonPrimaryNamesAttr = "non_primary_names"
+	regionsPrimaryRegionAttr   = "primary_region"
+	regionsSecondaryRegionAttr = "secondary_region"
+
+	regionNameAttr      = "name"
+	regionZonesAttr     = "zones"
+	regionPrimaryAttr   = "primary"
+	regionSecondaryAttr = "secondary"
+)
+
+func dataSourceRegions() *schema.Resource {
+	return &schema.Resource{
+		// This description is used by the documentation generator and the language server.
+		Description: "Data source used to list the regions of the cluster, or the regions of a multi-region database.",
+
+		ReadContext: dataSourceRegionsRead,
+
+		Schema: map[string]*schema.Schema{
+			regionsDatabaseAttr: {
+				Description: "Name of a database, lists the regions of the database instead of the regions of the cluster.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+			},
+			regionsAttr: {
+				Description: "Regions ordered by name.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						regionNameAttr: {
+							Description: "Name of the region.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						regionZonesAttr: {
+							Description: "Availability zones of the region.",
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						regionPrimaryAttr: {
+							Description: "True if the region is the primary region of `database`.",
+							Type:        schema.TypeBool,
+							Computed:    true,
+						},
+						regionSecondaryAttr: {
+							Description: "True if the region is the secondary region of `database`.",
+							Type:        schema.TypeBool,
+							Computed:    true,
+						},
+					},
+				},
+			},
+			regionsNamesAttr: {
+				Description: "Names of the regions.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			regionsNonPrimaryNamesAttr: {
+				Description: "Names of the regions except the primary region of `database`, as expected by the `regions` of `cockroach_database`.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			regionsPrimaryRegionAttr: {
+				Description: "Primary region of `database`.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			regionsSecondaryRegionAttr: {
+				Description: "Secondary region of `database`.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			argLocalPort: {
+				Description: "Local port to be used for port-forward. (default is 26269), use different port to avoid same port opening.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "26269",
+			},
+		},
+	}
+}
+
+func dataSourceRegionsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	local_port := d.Get(argLocalPort).(string)
+	database := d.Get(regionsDatabaseAttr).(string)
+	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
+
+	// stopCh control the port forwarding lifecycle. When it gets closed the
+	// port forward will terminate
+	stopCh := make(chan struct{}, 1)
+	// readyCh communicate when the port forward is ready to get traffic
+	readyCh := make(chan struct{})
+
+	tryPortForwardIfNeeded(ctx, d, meta, stopCh, readyCh, local_port)
+
+	conn, err := pgx.Connect(ctx, dns)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := conn.Ping(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+
+	query := `SELECT region, zones, false FROM [SHOW REGIONS FROM CLUSTER] ORDER BY region`
+
+	var secondary_region sql.NullString
+	if database != "" {
+		var (
+			id              int64
+			database_region sql.NullString
+		)
+		err = conn.QueryRow(ctx, `SELECT id, primary_region FROM crdb_internal.databases WHERE name = $1`, database).Scan(&id, &database_region)
+		if err == pgx.ErrNoRows {
+			return diag.Diagnostics{
+				{
+					Severity: diag.Error,
+					Summary:  "Database not found",
+					Detail:   fmt.Sprintf("Database %s does not exist in the cluster.", database),
+				},
+			}
+		}
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		// secondary regions only exist for multi-region databases of
+		// clusters running v22.1 or later
+		if database_region.String != "" {
+			multi_region, err := clusterVersionAtLeast(ctx, conn, "22.1")
+			if err != nil {
+				return diag.FromErr(err)
+			}
+
+			if multi_region {
+				err = conn.QueryRow(ctx, `SELECT secondary_region FROM crdb_internal.databases WHERE id = $1`, id).Scan(&secondary_region)
+				if err != nil {
+					return diag.FromErr(err)
+				}
+			}
+		}
+
+		query = `SELECT region, zones, "primary" FROM [SHOW REGIONS FROM DATABASE ` + pq.QuoteIdentifier(database) + `] ORDER BY region`
+	}
+
+	rows, err := conn.Query(ctx, query)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer rows.Close()
+
+	regions := []interface{}{}
+	names := []string{}
+	non_primary_names := []string{}
+	primary_region := ""
+	for rows.Next() {
+		var (
+			name    string
+			zones   []string
+			primary bool
+		)
+		if err := rows.Scan(&name, &zones, &primary); err != nil {
+			return diag.FromErr(err)
+		}
+
+		if zones == nil {
+			zones = []string{}
+		}
+
+		if primary {
+			primary_region = name
+		} else {
+			non_primary_names = append(non_primary_names, name)
+		}
+
+		names = append(names, name)
+		regions = append(regions, map[string]interface{}{
+			regionNameAttr:      name,
+			regionZonesAttr:     zones,
+			regionPrimaryAttr:   primary,
+			regionSecondaryAttr: secondary_region.Valid && secondary_region.String == name,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if database != "" {
+		d.SetId(database)
+	} else {
+		d.SetId("cluster")
+	}
+	if err := d.Set(regionsAttr, regions); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(regionsNamesAttr, names); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(regionsNonPrimaryNamesAttr, non_primary_names); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(regionsPrimaryRegionAttr, primary_region); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(regionsSecondaryRegionAttr, secondary_region.String); err != nil {
+		return diag.FromErr(err)
+	}
+
+	close(stopCh)
+
+	return diag.Diagnostics{}
+}
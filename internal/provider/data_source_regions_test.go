@@ -0,0 +1,30 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceRegions(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceRegions,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"data.cockroach_regions.foo", "primary_region", ""),
+					resource.TestCheckResourceAttrPair(
+						"data.cockroach_regions.foo", "non_primary_names.#",
+						"data.cockroach_regions.foo", "names.#"),
+				),
+			},
+		},
+	})
+}
+
+const testAccDataSourceRegions = `
+data "cockroach_regions" "foo" {}
+`
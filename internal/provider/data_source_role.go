@@ -0,0 +1,224 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	roleNameAttr               = "name"
+	roleCanLoginAttr           = "can_login"
+	roleMemberOfAttr           = "member_of"
+	roleMemberOfTransitiveAttr = "member_of_transitive"
+	roleAdminOfAttr            = "admin_of"
+)
+
+func dataSourceRole() *schema.Resource {
+	s := roleSchema()
+	s[roleNameAttr] = &schema.Schema{
+		Description: "Name of the role or user.",
+		Type:        schema.TypeString,
+		Required:    true,
+	}
+	s[argLocalPort] = &schema.Schema{
+		Description: "Local port to be used for port-forward. (default is 26266), use different port to avoid same port opening.",
+		Type:        schema.TypeString,
+		Optional:    true,
+		Default:     "26266",
+	}
+
+	return &schema.Resource{
+		// This description is used by the documentation generator and the language server.
+		Description: "Data source used to read an existing role or user, with its options and memberships.",
+
+		ReadContext: dataSourceRoleRead,
+
+		Schema: s,
+	}
+}
+
+// roleSchema returns the computed attributes of a role, shared by the
+// cockroach_role and cockroach_roles data sources.
+func roleSchema() map[string]*schema.Schema {
+	s := map[string]*schema.Schema{
+		roleCanLoginAttr: {
+			Description: "True if the role can log in with SQL clients.",
+			Type:        schema.TypeBool,
+			Computed:    true,
+		},
+		dbAdminAttr: {
+			Description: "True if the role is a direct or indirect member of `admin`.",
+			Type:        schema.TypeBool,
+			Computed:    true,
+		},
+		dbValidUntilAttr: {
+			Description: "Date and time after which the password of the role is no longer valid.",
+			Type:        schema.TypeString,
+			Computed:    true,
+		},
+		dbConnectionLimitAttr: {
+			Description: "Maximum number of concurrent connections of the role, -1 means no limit.",
+			Type:        schema.TypeInt,
+			Computed:    true,
+		},
+		roleMemberOfAttr: {
+			Description: "Roles the role is a direct member of.",
+			Type:        schema.TypeList,
+			Computed:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+		},
+		roleMemberOfTransitiveAttr: {
+			Description: "Roles the role is a direct or indirect member of.",
+			Type:        schema.TypeList,
+			Computed:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+		},
+		roleAdminOfAttr: {
+			Description: "Roles the role is a member of with the admin option, so it can grant and revoke their membership.",
+			Type:        schema.TypeList,
+			Computed:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+		},
+	}
+
+	for _, option := range roleOptions {
+		s[option.attr] = &schema.Schema{
+			Description: option.description,
+			Type:        schema.TypeBool,
+			Computed:    true,
+		}
+	}
+
+	return s
+}
+
+func dataSourceRoleRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	local_port := d.Get(argLocalPort).(string)
+	name := d.Get(roleNameAttr).(string)
+	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
+
+	// stopCh control the port forwarding lifecycle. When it gets closed the
+	// port forward will terminate
+	stopCh := make(chan struct{}, 1)
+	// readyCh communicate when the port forward is ready to get traffic
+	readyCh := make(chan struct{})
+
+	tryPortForwardIfNeeded(ctx, d, meta, stopCh, readyCh, local_port)
+
+	conn, err := pgx.Connect(ctx, dns)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := conn.Ping(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+
+	var exists bool
+	err = conn.QueryRow(ctx, `SELECT count(*) > 0 FROM system.users WHERE username = $1`, name).Scan(&exists)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if !exists {
+		return diag.Diagnostics{
+			{
+				Severity: diag.Error,
+				Summary:  "Role not found",
+				Detail:   fmt.Sprintf("Role %s does not exist in the cluster.", name),
+			},
+		}
+	}
+
+	role, err := readRole(ctx, conn, name)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(name)
+	for attr, value := range role {
+		if err := d.Set(attr, value); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	close(stopCh)
+
+	return diag.Diagnostics{}
+}
+
+// readRole reads the options and memberships of an existing role, keyed by
+// the attributes of roleSchema.
+func readRole(ctx context.Context, conn *pgx.Conn, name string) (map[string]interface{}, error) {
+	options, err := readRoleOptions(ctx, conn, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var connection_limit int
+	err = conn.QueryRow(ctx, `SELECT rolconnlimit FROM pg_catalog.pg_roles WHERE rolname = $1`, name).Scan(&connection_limit)
+	if err != nil {
+		return nil, err
+	}
+
+	member_of, err := queryStrings(ctx, conn, `SELECT role FROM system.role_members WHERE member = $1 ORDER BY role`, name)
+	if err != nil {
+		return nil, err
+	}
+
+	member_of_transitive, err := queryStrings(ctx, conn,
+		`WITH RECURSIVE memberships (role) AS (
+			SELECT role FROM system.role_members WHERE member = $1
+			UNION
+			SELECT m.role FROM system.role_members AS m JOIN memberships ON m.member = memberships.role
+		)
+		SELECT role FROM memberships ORDER BY role`,
+		name,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	admin_of, err := queryStrings(ctx, conn, `SELECT role FROM system.role_members WHERE member = $1 AND "isAdmin" ORDER BY role`, name)
+	if err != nil {
+		return nil, err
+	}
+
+	role := map[string]interface{}{
+		roleNameAttr:               name,
+		dbAdminAttr:                name == "admin" || contains(member_of_transitive, "admin"),
+		dbValidUntilAttr:           options["VALID UNTIL"],
+		dbConnectionLimitAttr:      connection_limit,
+		roleMemberOfAttr:           member_of,
+		roleMemberOfTransitiveAttr: member_of_transitive,
+		roleAdminOfAttr:            admin_of,
+	}
+
+	for _, option := range roleOptions {
+		role[option.attr] = roleOptionEnabled(options, option)
+	}
+
+	role[roleCanLoginAttr] = roleCanLogin(options)
+
+	return role, nil
+}
+
+// roleCanLogin tells if a role with the given options can open SQL sessions,
+// which requires both LOGIN and SQLLOGIN.
+func roleCanLogin(options map[string]string) bool {
+	for _, option := range roleOptions {
+		if (option.attr == "login" || option.attr == "sqllogin") && !roleOptionEnabled(options, option) {
+			return false
+		}
+	}
+
+	return true
+}
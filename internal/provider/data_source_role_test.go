@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceRole(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceRole,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"data.cockroach_role.foo", "is_admin", "true"),
+					resource.TestCheckResourceAttr(
+						"data.cockroach_role.foo", "can_login", "true"),
+				),
+			},
+		},
+	})
+}
+
+const testAccDataSourceRole = `
+data "cockroach_role" "foo" {
+  name = "root"
+}
+`
+
+func TestRoleCanLogin(t *testing.T) {
+	cases := []struct {
+		options  map[string]string
+		expected bool
+	}{
+		{map[string]string{}, true},
+		{map[string]string{"CREATEDB": ""}, true},
+		{map[string]string{"NOLOGIN": ""}, false},
+		{map[string]string{"NOSQLLOGIN": ""}, false},
+	}
+
+	for _, c := range cases {
+		if can_login := roleCanLogin(c.options); can_login != c.expected {
+			t.Errorf("roleCanLogin(%v) = %t, expected %t", c.options, can_login, c.expected)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package provider
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	rolesNameRegexAttr = "name_regex"
+	rolesMemberOfAttr  = "member_of"
+	rolesCanLoginAttr  = "can_login"
+	rolesAttr          = "roles"
+)
+
+func dataSourceRoles() *schema.Resource {
+	role := roleSchema()
+	role[roleNameAttr] = &schema.Schema{
+		Description: "Name of the role or user.",
+		Type:        schema.TypeString,
+		Computed:    true,
+	}
+
+	return &schema.Resource{
+		// This description is used by the documentation generator and the language server.
+		Description: "Data source used to list the roles and users of the cluster, with their options and memberships.",
+
+		ReadContext: dataSourceRolesRead,
+
+		Schema: map[string]*schema.Schema{
+			rolesNameRegexAttr: {
+				Description:  "Only list the roles whose name matches this regular expression.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+			rolesMemberOfAttr: {
+				Description: "Only list the direct or indirect members of this role.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+			},
+			rolesCanLoginAttr: {
+				Description:  "Only list the roles which can (`true`) or cannot (`false`) log in.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validation.StringInSlice([]string{"", "true", "false"}, false),
+			},
+			rolesAttr: {
+				Description: "Roles matching the filters, ordered by name.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: role,
+				},
+			},
+			argLocalPort: {
+				Description: "Local port to be used for port-forward. (default is 26267), use different port to avoid same port opening.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "26267",
+			},
+		},
+	}
+}
+
+func dataSourceRolesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	local_port := d.Get(argLocalPort).(string)
+	name_regex := d.Get(rolesNameRegexAttr).(string)
+	member_of := d.Get(rolesMemberOfAttr).(string)
+	can_login := d.Get(rolesCanLoginAttr).(string)
+	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
+
+	name_filter, err := regexp.Compile(name_regex)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// stopCh control the port forwarding lifecycle. When it gets closed the
+	// port forward will terminate
+	stopCh := make(chan struct{}, 1)
+	// readyCh communicate when the port forward is ready to get traffic
+	readyCh := make(chan struct{})
+
+	tryPortForwardIfNeeded(ctx, d, meta, stopCh, readyCh, local_port)
+
+	conn, err := pgx.Connect(ctx, dns)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := conn.Ping(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+
+	names, err := queryStrings(ctx, conn, `SELECT username FROM [SHOW USERS] ORDER BY username`)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	roles := []interface{}{}
+	for _, name := range names {
+		if !name_filter.MatchString(name) {
+			continue
+		}
+
+		role, err := readRole(ctx, conn, name)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		if member_of != "" && !contains(role[roleMemberOfTransitiveAttr].([]string), member_of) {
+			continue
+		}
+
+		if can_login != "" && strconv.FormatBool(role[roleCanLoginAttr].(bool)) != can_login {
+			continue
+		}
+
+		roles = append(roles, role)
+	}
+
+	d.SetId(strconv.Itoa(schema.HashString(name_regex + "/" + member_of + "/" + can_login)))
+	if err := d.Set(rolesAttr, roles); err != nil {
+		return diag.FromErr(err)
+	}
+
+	close(stopCh)
+
+	return diag.Diagnostics{}
+}
@@ -0,0 +1,30 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceRoles(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceRoles,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"data.cockroach_roles.foo", "roles.0.name", "root"),
+				),
+			},
+		},
+	})
+}
+
+const testAccDataSourceRoles = `
+data "cockroach_roles" "foo" {
+  name_regex = "^root$"
+  member_of  = "admin"
+}
+`
@@ -0,0 +1,233 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	schedulesTypeAttr = "type"
+	schedulesAttr     = "schedules"
+
+	scheduleIdAttr         = "id"
+	scheduleLabelAttr      = "label"
+	scheduleTypeAttr       = "type"
+	scheduleOwnerAttr      = "owner"
+	scheduleStatusAttr     = "status"
+	scheduleStateAttr      = "state"
+	scheduleRecurrenceAttr = "recurrence"
+	scheduleNextRunAttr    = "next_run"
+	scheduleCommandAttr    = "command"
+	schedulePausedAttr     = "paused"
+	scheduleCreatedAttr    = "created"
+)
+
+// executor types of system.scheduled_jobs, keyed by the schedule types of
+// the cockroach_schedules data source
+var scheduleExecutorTypes = map[string]string{
+	"backup":               "scheduled-backup-executor",
+	"changefeed":           "scheduled-changefeed-executor",
+	"sql_stats_compaction": "scheduled-sql-stats-compaction-executor",
+}
+
+func dataSourceSchedules() *schema.Resource {
+	return &schema.Resource{
+		// This description is used by the documentation generator and the language server.
+		Description: "Data source used to list the schedules of the cluster with `SHOW SCHEDULES`, such as backup, changefeed and SQL stats compaction schedules.",
+
+		ReadContext: dataSourceSchedulesRead,
+
+		Schema: map[string]*schema.Schema{
+			schedulesTypeAttr: {
+				Description:  "Only list the schedules of this type, `backup`, `changefeed` or `sql_stats_compaction`.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validation.StringInSlice([]string{"", "backup", "changefeed", "sql_stats_compaction"}, false),
+			},
+			schedulesAttr: {
+				Description: "Schedules matching the filters, ordered by ID.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						scheduleIdAttr: {
+							Description: "ID of the schedule.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						scheduleLabelAttr: {
+							Description: "Label of the schedule.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						scheduleTypeAttr: {
+							Description: "Type of the schedule, `backup`, `changefeed`, `sql_stats_compaction` or the executor type of other schedules.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						scheduleOwnerAttr: {
+							Description: "Owner of the schedule.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						scheduleStatusAttr: {
+							Description: "Status of the schedule, `ACTIVE` or `PAUSED`.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						scheduleStateAttr: {
+							Description: "State of the schedule, such as the error of its last run.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						scheduleRecurrenceAttr: {
+							Description: "Recurrence of the schedule, as a crontab expression.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						scheduleNextRunAttr: {
+							Description: "Time of the next run, in RFC3339 format, empty for a paused schedule.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						scheduleCommandAttr: {
+							Description: "Command run by the schedule, as JSON.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						schedulePausedAttr: {
+							Description: "True if the schedule is paused.",
+							Type:        schema.TypeBool,
+							Computed:    true,
+						},
+						scheduleCreatedAttr: {
+							Description: "Creation time of the schedule, in RFC3339 format.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+					},
+				},
+			},
+			argLocalPort: {
+				Description: "Local port to be used for port-forward. (default is 26275), use different port to avoid same port opening.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "26275",
+			},
+		},
+	}
+}
+
+func dataSourceSchedulesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	local_port := d.Get(argLocalPort).(string)
+	schedule_type := d.Get(schedulesTypeAttr).(string)
+	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
+
+	// stopCh control the port forwarding lifecycle. When it gets closed the
+	// port forward will terminate
+	stopCh := make(chan struct{}, 1)
+	// readyCh communicate when the port forward is ready to get traffic
+	readyCh := make(chan struct{})
+
+	tryPortForwardIfNeeded(ctx, d, meta, stopCh, readyCh, local_port)
+
+	conn, err := pgx.Connect(ctx, dns)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := conn.Ping(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// SHOW SCHEDULES does not return the executor type of the schedules
+	rows, err := conn.Query(ctx,
+		`SELECT s.id, s.label, j.executor_type, s.owner, s.schedule_status, s.state, s.recurrence, s.next_run, s.command::STRING, s.created
+		FROM [SHOW SCHEDULES] AS s
+		JOIN system.scheduled_jobs AS j ON j.schedule_id = s.id
+		WHERE $1 = '' OR j.executor_type = $1
+		ORDER BY s.id`,
+		scheduleExecutorTypes[schedule_type],
+	)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer rows.Close()
+
+	schedules := []interface{}{}
+	ids := []string{}
+	for rows.Next() {
+		var (
+			id            int64
+			label         string
+			executor_type string
+			owner         string
+			status        string
+			state         sql.NullString
+			recurrence    sql.NullString
+			next_run      *time.Time
+			command       sql.NullString
+			created       time.Time
+		)
+		if err := rows.Scan(&id, &label, &executor_type, &owner, &status, &state, &recurrence, &next_run, &command, &created); err != nil {
+			return diag.FromErr(err)
+		}
+
+		next_run_at := ""
+		if next_run != nil {
+			next_run_at = next_run.UTC().Format(time.RFC3339)
+		}
+
+		ids = append(ids, strconv.FormatInt(id, 10))
+		schedules = append(schedules, map[string]interface{}{
+			scheduleIdAttr:         strconv.FormatInt(id, 10),
+			scheduleLabelAttr:      label,
+			scheduleTypeAttr:       scheduleType(executor_type),
+			scheduleOwnerAttr:      owner,
+			scheduleStatusAttr:     status,
+			scheduleStateAttr:      state.String,
+			scheduleRecurrenceAttr: recurrence.String,
+			scheduleNextRunAttr:    next_run_at,
+			scheduleCommandAttr:    command.String,
+			schedulePausedAttr:     status == "PAUSED",
+			scheduleCreatedAttr:    created.UTC().Format(time.RFC3339),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// the id changes whenever the set of schedules changes
+	d.SetId(strconv.Itoa(schema.HashString(schedule_type + "/" + strings.Join(ids, ","))))
+	if err := d.Set(schedulesAttr, schedules); err != nil {
+		return diag.FromErr(err)
+	}
+
+	close(stopCh)
+
+	return diag.Diagnostics{}
+}
+
+// scheduleType returns the schedule type of an executor type, unknown
+// executor types are returned as they are.
+func scheduleType(executor_type string) string {
+	for schedule_type, executor := range scheduleExecutorTypes {
+		if executor == executor_type {
+			return schedule_type
+		}
+	}
+
+	return executor_type
+}
@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceSchedules(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceSchedules,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"data.cockroach_schedules.foo", "schedules.0.type", "sql_stats_compaction"),
+					resource.TestCheckResourceAttr(
+						"data.cockroach_schedules.foo", "schedules.0.paused", "false"),
+				),
+			},
+		},
+	})
+}
+
+const testAccDataSourceSchedules = `
+data "cockroach_schedules" "foo" {
+  type = "sql_stats_compaction"
+}
+`
+
+func TestScheduleType(t *testing.T) {
+	for executor_type, expected := range map[string]string{
+		"scheduled-backup-executor":               "backup",
+		"scheduled-changefeed-executor":           "changefeed",
+		"scheduled-sql-stats-compaction-executor": "sql_stats_compaction",
+		"scheduled-unknown-executor":              "scheduled-unknown-executor",
+	} {
+		if schedule_type := scheduleType(executor_type); schedule_type != expected {
+			t.Errorf("expected %s, got %s", expected, schedule_type)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/jackc/pgx/v4"
+	"github.com/lib/pq"
+)
+
+const (
+	queryQueryAttr          = "query"
+	queryParametersAttr     = "parameters"
+	queryDatabaseAttr       = "database"
+	queryAsOfSystemTimeAttr = "as_of_system_time"
+	queryColumnsAttr        = "columns"
+	queryRowsAttr           = "rows"
+
+	columnNameAttr = "name"
+	columnTypeAttr = "type"
+)
+
+var (
+	// statements which only read, the query also runs in a READ ONLY
+	// transaction so writes hidden in a statement still fail
+	readStatements = []string{"SELECT", "WITH", "SHOW", "VALUES", "TABLE", "EXPLAIN"}
+
+	sqlCommentRegexp  = regexp.MustCompile(`(?s)^(\s|--[^\n]*(\n|$)|/\*.*?\*/)*`)
+	sqlFunctionRegexp = regexp.MustCompile(`^[a-z_]+\(\)$`)
+)
+
+func dataSourceSqlQuery() *schema.Resource {
+	return &schema.Resource{
+		// This description is used by the documentation generator and the language server.
+		Description: "Data source used to run a read-only query against the cluster. " +
+			"The query runs inside a `READ ONLY` transaction and statements other than `SELECT`, `WITH`, `SHOW`, `VALUES`, `TABLE` and `EXPLAIN` are rejected.",
+
+		ReadContext: dataSourceSqlQueryRead,
+
+		Schema: map[string]*schema.Schema{
+			queryQueryAttr: {
+				Description: "Query to run, parameters are referenced as `$1`, `$2`...",
+				Type:        schema.TypeString,
+				Required:    true,
+			},
+			queryParametersAttr: {
+				Description: "Values of the parameters of the query, in order.",
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			queryDatabaseAttr: {
+				Description: "Database the query runs in. (default is the database of the provider connection)",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+			},
+			queryAsOfSystemTimeAttr: {
+				Description: "Run the query at a past time, for example `-10s` or `follower_read_timestamp()`.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+			},
+			queryColumnsAttr: {
+				Description: "Columns of the result.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						columnNameAttr: {
+							Description: "Name of the column.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						columnTypeAttr: {
+							Description: "Type of the column, for example `int8` or `text`.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+					},
+				},
+			},
+			queryRowsAttr: {
+				Description: "Rows of the result, each row maps the column names to the text representation of the values. NULL values are empty strings, column names must be unique.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Schema{
+					Type: schema.TypeMap,
+					Elem: &schema.Schema{Type: schema.TypeString},
+				},
+			},
+			argLocalPort: {
+				Description: "Local port to be used for port-forward. (default is 26270), use different port to avoid same port opening.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "26270",
+			},
+		},
+	}
+}
+
+func dataSourceSqlQueryRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	local_port := d.Get(argLocalPort).(string)
+	query := d.Get(queryQueryAttr).(string)
+	parameters := convertToString(d.Get(queryParametersAttr).([]interface{}))
+	database := d.Get(queryDatabaseAttr).(string)
+	as_of_system_time := d.Get(queryAsOfSystemTimeAttr).(string)
+	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
+
+	if err := checkReadStatement(query); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// stopCh control the port forwarding lifecycle. When it gets closed the
+	// port forward will terminate
+	stopCh := make(chan struct{}, 1)
+	// readyCh communicate when the port forward is ready to get traffic
+	readyCh := make(chan struct{})
+
+	tryPortForwardIfNeeded(ctx, d, meta, stopCh, readyCh, local_port)
+
+	conn, err := pgx.Connect(ctx, dns)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := conn.Ping(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if database != "" {
+		if _, err := conn.Exec(ctx, `SET database = `+pq.QuoteIdentifier(database)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	// nothing is written, the transaction is always rolled back
+	defer tx.Rollback(ctx)
+
+	if as_of_system_time != "" {
+		if _, err := tx.Exec(ctx, `SET TRANSACTION AS OF SYSTEM TIME `+asOfSystemTimeExpr(as_of_system_time)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	values := make([][]byte, len(parameters))
+	for i, parameter := range parameters {
+		values[i] = []byte(parameter)
+	}
+
+	// parameters and results use the text format so every value converts to
+	// a string
+	result := tx.Conn().PgConn().ExecParams(ctx, query, values, nil, nil, nil).Read()
+	if result.Err != nil {
+		return diag.FromErr(result.Err)
+	}
+
+	columns := make([]interface{}, len(result.FieldDescriptions))
+	names := make([]string, len(result.FieldDescriptions))
+	for i, field := range result.FieldDescriptions {
+		names[i] = string(field.Name)
+
+		type_name := strconv.FormatUint(uint64(field.DataTypeOID), 10)
+		if data_type, ok := conn.ConnInfo().DataTypeForOID(field.DataTypeOID); ok {
+			type_name = data_type.Name
+		}
+
+		columns[i] = map[string]interface{}{
+			columnNameAttr: names[i],
+			columnTypeAttr: type_name,
+		}
+	}
+
+	// rows are keyed by column name, duplicates would overwrite each other
+	if name := duplicateColumn(names); name != "" {
+		return diag.Errorf("column %s appears more than once in the result, give the columns distinct names with AS", name)
+	}
+
+	rows := make([]interface{}, len(result.Rows))
+	for i, row := range result.Rows {
+		values := map[string]interface{}{}
+		for j, value := range row {
+			values[names[j]] = string(value)
+		}
+		rows[i] = values
+	}
+
+	d.SetId(strconv.Itoa(schema.HashString(database + "/" + as_of_system_time + "/" + query + "/" + strings.Join(parameters, ","))))
+	if err := d.Set(queryColumnsAttr, columns); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(queryRowsAttr, rows); err != nil {
+		return diag.FromErr(err)
+	}
+
+	close(stopCh)
+
+	return diag.Diagnostics{}
+}
+
+// duplicateColumn returns the first column name which appears more than once,
+// or an empty string.
+func duplicateColumn(names []string) string {
+	seen := map[string]bool{}
+	for _, name := range names {
+		if seen[name] {
+			return name
+		}
+		seen[name] = true
+	}
+
+	return ""
+}
+
+// checkReadStatement rejects statements which do not start with one of the
+// readStatements keywords.
+func checkReadStatement(query string) error {
+	statement := strings.TrimLeft(sqlCommentRegexp.ReplaceAllString(query, ""), "( \t\n")
+
+	words := strings.FieldsFunc(statement, func(c rune) bool {
+		return c == ' ' || c == '\t' || c == '\n' || c == '(' || c == ';'
+	})
+	if len(words) == 0 {
+		return fmt.Errorf("the query is empty")
+	}
+
+	keyword := strings.ToUpper(words[0])
+	if !contains(readStatements, keyword) {
+		return fmt.Errorf("only read statements are allowed, the query starts with %s", keyword)
+	}
+
+	return nil
+}
+
+// asOfSystemTimeExpr quotes intervals and timestamps, functions such as
+// follower_read_timestamp() are used as they are.
+func asOfSystemTimeExpr(value string) string {
+	if sqlFunctionRegexp.MatchString(value) {
+		return value
+	}
+
+	return pq.QuoteLiteral(value)
+}
@@ -0,0 +1,85 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceSqlQuery(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceSqlQuery,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"data.cockroach_sql_query.foo", "rows.0.answer", "42"),
+					resource.TestCheckResourceAttr(
+						"data.cockroach_sql_query.foo", "columns.0.type", "int8"),
+				),
+			},
+		},
+	})
+}
+
+const testAccDataSourceSqlQuery = `
+data "cockroach_sql_query" "foo" {
+  query      = "SELECT $1::INT8 AS answer"
+  parameters = ["42"]
+}
+`
+
+func TestCheckReadStatement(t *testing.T) {
+	for _, query := range []string{
+		"SELECT 1",
+		"  select * FROM t",
+		"(SELECT 1) UNION (SELECT 2)",
+		"-- comment\nWITH x AS (SELECT 1) SELECT * FROM x",
+		"/* comment */ SHOW DATABASES",
+		"VALUES (1)",
+		"TABLE t",
+		"EXPLAIN SELECT 1",
+	} {
+		if err := checkReadStatement(query); err != nil {
+			t.Errorf("expected %q to be allowed, got %v", query, err)
+		}
+	}
+
+	for _, query := range []string{
+		"",
+		"-- SELECT",
+		"INSERT INTO t VALUES (1)",
+		"/* SELECT */ DELETE FROM t",
+		"DROP DATABASE d",
+		"SET CLUSTER SETTING version = '22.1'",
+	} {
+		if err := checkReadStatement(query); err == nil {
+			t.Errorf("expected %q to be rejected", query)
+		}
+	}
+}
+
+func TestAsOfSystemTimeExpr(t *testing.T) {
+	for value, expected := range map[string]string{
+		"-10s":                      "'-10s'",
+		"follower_read_timestamp()": "follower_read_timestamp()",
+		"2022-10-01 10:00:00":       "'2022-10-01 10:00:00'",
+		"'; DROP DATABASE d; --":    "'''; DROP DATABASE d; --'",
+	} {
+		if expr := asOfSystemTimeExpr(value); expr != expected {
+			t.Errorf("expected %s, got %s", expected, expr)
+		}
+	}
+}
+
+func TestDuplicateColumn(t *testing.T) {
+	if name := duplicateColumn([]string{"id", "name"}); name != "" {
+		t.Errorf("expected no duplicate column, got %s", name)
+	}
+
+	if name := duplicateColumn([]string{"?column?", "id", "?column?"}); name != "?column?" {
+		t.Errorf("expected ?column? to be a duplicate, got %q", name)
+	}
+}
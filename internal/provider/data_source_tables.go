@@ -0,0 +1,256 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/jackc/pgx/v4"
+	"github.com/lib/pq"
+)
+
+const (
+	tablesDatabaseAttr  = "database"
+	tablesSchemaAttr    = "schema"
+	tablesNameRegexAttr = "name_regex"
+	tablesAttr          = "tables"
+
+	tablesIdAttr                = "id"
+	tablesTableSchemaAttr       = "schema"
+	tablesTableNameAttr         = "name"
+	tablesLocalityAttr          = "locality"
+	tablesLocalityRegionAttr    = "locality_region"
+	tablesLocalityColumnAttr    = "locality_column"
+	tablesEstimatedRowCountAttr = "estimated_row_count"
+	tablesLiveBytesAttr         = "live_bytes"
+	tablesRangeCountAttr        = "range_count"
+)
+
+func dataSourceTables() *schema.Resource {
+	return &schema.Resource{
+		// This description is used by the documentation generator and the language server.
+		Description: "Data source used to list the tables of a database with their locality, size and range statistics.",
+
+		ReadContext: dataSourceTablesRead,
+
+		Schema: map[string]*schema.Schema{
+			tablesDatabaseAttr: {
+				Description: "Database of the tables.",
+				Type:        schema.TypeString,
+				Required:    true,
+			},
+			tablesSchemaAttr: {
+				Description: "Only list the tables of this schema.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+			},
+			tablesNameRegexAttr: {
+				Description:  "Only list the tables whose name matches this regular expression.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+			tablesAttr: {
+				Description: "Tables matching the filters, ordered by schema and name.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						tablesIdAttr: {
+							Description: "ID of the table.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						tablesTableSchemaAttr: {
+							Description: "Schema of the table.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						tablesTableNameAttr: {
+							Description: "Name of the table.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						tablesLocalityAttr: {
+							Description: "Locality of a table of a multi-region database, `global`, `regional_by_table` or `regional_by_row`.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						tablesLocalityRegionAttr: {
+							Description: "Region of a `regional_by_table` table, empty for the primary region.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						tablesLocalityColumnAttr: {
+							Description: "Region column of a `regional_by_row` table, empty for the default `crdb_region` column.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						tablesEstimatedRowCountAttr: {
+							Description: "Estimated number of rows, from the table statistics.",
+							Type:        schema.TypeInt,
+							Computed:    true,
+						},
+						tablesLiveBytesAttr: {
+							Description: "Approximate size of the ranges holding the table. From v23.1 on, adjacent tables can share a range, so this is an upper bound of the size of the table.",
+							Type:        schema.TypeInt,
+							Computed:    true,
+						},
+						tablesRangeCountAttr: {
+							Description: "Number of ranges of the table.",
+							Type:        schema.TypeInt,
+							Computed:    true,
+						},
+					},
+				},
+			},
+			argLocalPort: {
+				Description: "Local port to be used for port-forward. (default is 26273), use different port to avoid same port opening.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "26273",
+			},
+		},
+	}
+}
+
+func dataSourceTablesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	local_port := d.Get(argLocalPort).(string)
+	database := d.Get(tablesDatabaseAttr).(string)
+	schema_name := d.Get(tablesSchemaAttr).(string)
+	name_regex := d.Get(tablesNameRegexAttr).(string)
+	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
+
+	// stopCh control the port forwarding lifecycle. When it gets closed the
+	// port forward will terminate
+	stopCh := make(chan struct{}, 1)
+	// readyCh communicate when the port forward is ready to get traffic
+	readyCh := make(chan struct{})
+
+	tryPortForwardIfNeeded(ctx, d, meta, stopCh, readyCh, local_port)
+
+	conn, err := pgx.Connect(ctx, dns)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := conn.Ping(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+
+	var exists bool
+	err = conn.QueryRow(ctx, `SELECT count(*) > 0 FROM crdb_internal.databases WHERE name = $1`, database).Scan(&exists)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if !exists {
+		return diag.Diagnostics{
+			{
+				Severity: diag.Error,
+				Summary:  "Database not found",
+				Detail:   fmt.Sprintf("Database %s does not exist in the cluster.", database),
+			},
+		}
+	}
+
+	quoted_name := pq.QuoteIdentifier(database)
+
+	// table_row_statistics only covers the tables of the database it is
+	// read from
+	rows, err := conn.Query(ctx,
+		`SELECT t.table_id, t.schema_name, t.name, t.locality, COALESCE(s.estimated_row_count, 0)
+		FROM crdb_internal.tables AS t
+		JOIN `+quoted_name+`.information_schema.tables AS i
+			ON i.table_schema = t.schema_name AND i.table_name = t.name AND i.table_type = 'BASE TABLE'
+		LEFT JOIN `+quoted_name+`.crdb_internal.table_row_statistics AS s ON s.table_id = t.table_id
+		WHERE t.database_name = $1 AND t.drop_time IS NULL
+			AND ($2 = '' OR t.schema_name = $2)
+			AND ($3 = '' OR t.name ~ $3)
+		ORDER BY t.schema_name, t.name`,
+		database, schema_name, name_regex,
+	)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer rows.Close()
+
+	tables := []interface{}{}
+	ids := []string{}
+	for rows.Next() {
+		var (
+			id                  int64
+			table_schema        string
+			name                string
+			table_locality      sql.NullString
+			estimated_row_count int64
+		)
+		if err := rows.Scan(&id, &table_schema, &name, &table_locality, &estimated_row_count); err != nil {
+			return diag.FromErr(err)
+		}
+
+		// tables of databases which are not multi-region have no locality
+		locality, region, column := "", "", ""
+		if table_locality.Valid {
+			locality, region, column = parseTableLocality(table_locality.String)
+		}
+
+		ids = append(ids, strconv.FormatInt(id, 10))
+		tables = append(tables, map[string]interface{}{
+			tablesIdAttr:                strconv.FormatInt(id, 10),
+			tablesTableSchemaAttr:       table_schema,
+			tablesTableNameAttr:         name,
+			tablesLocalityAttr:          locality,
+			tablesLocalityRegionAttr:    region,
+			tablesLocalityColumnAttr:    column,
+			tablesEstimatedRowCountAttr: int(estimated_row_count),
+			tablesLiveBytesAttr:         0,
+			tablesRangeCountAttr:        0,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return diag.FromErr(err)
+	}
+	rows.Close()
+
+	if len(tables) != 0 {
+		detailed_ranges, err := clusterVersionAtLeast(ctx, conn, "23.1")
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		for _, raw := range tables {
+			table := raw.(map[string]interface{})
+			target := "TABLE " + quoted_name + "." +
+				pq.QuoteIdentifier(table[tablesTableSchemaAttr].(string)) + "." +
+				pq.QuoteIdentifier(table[tablesTableNameAttr].(string))
+
+			range_count, live_bytes, err := readRangeStats(ctx, conn, target, detailed_ranges)
+			if err != nil {
+				return diag.FromErr(err)
+			}
+			table[tablesRangeCountAttr] = int(range_count)
+			table[tablesLiveBytesAttr] = int(live_bytes)
+		}
+	}
+
+	// the id changes whenever the set of tables changes
+	d.SetId(strconv.Itoa(schema.HashString(database + "/" + strings.Join(ids, ","))))
+	if err := d.Set(tablesAttr, tables); err != nil {
+		return diag.FromErr(err)
+	}
+
+	close(stopCh)
+
+	return diag.Diagnostics{}
+}
@@ -0,0 +1,32 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceTables(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceTables,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"data.cockroach_tables.foo", "tables.0.schema", "public"),
+					resource.TestCheckResourceAttr(
+						"data.cockroach_tables.foo", "tables.0.name", "users"),
+				),
+			},
+		},
+	})
+}
+
+const testAccDataSourceTables = `
+data "cockroach_tables" "foo" {
+  database   = "system"
+  name_regex = "^users$"
+}
+`
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/jackc/pgx/v4"
+	"github.com/lib/pq"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
@@ -14,6 +16,7 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 )
 
@@ -168,3 +171,85 @@ func contains(elems []string, v string) bool {
 	}
 	return false
 }
+
+func subsetOf(a []string, b []string) bool {
+	for _, v := range a {
+		if !contains(b, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameElements(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, v := range a {
+		if !contains(b, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func quoteIdentifiers(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = pq.QuoteIdentifier(name)
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+// unquoteIdentifier reverses pq.QuoteIdentifier, identifiers which are not
+// quoted are returned as they are.
+func unquoteIdentifier(name string) string {
+	if len(name) >= 2 && strings.HasPrefix(name, `"`) && strings.HasSuffix(name, `"`) {
+		return strings.ReplaceAll(name[1:len(name)-1], `""`, `"`)
+	}
+
+	return name
+}
+
+// readDatabaseNames lists the databases of the cluster, except the system
+// database.
+func readDatabaseNames(ctx context.Context, conn *pgx.Conn) ([]string, error) {
+	rows, err := conn.Query(ctx, `SELECT name FROM crdb_internal.databases WHERE name != 'system' ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	databases := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		databases = append(databases, name)
+	}
+
+	return databases, rows.Err()
+}
+
+// rangeStatsStatement counts the ranges of target, such as TABLE "orders"."t",
+// and sums their size. Clusters running v23.1 or later only report the size of
+// a range WITH DETAILS, older versions report range_size_mb.
+func rangeStatsStatement(target string, detailed bool) string {
+	if detailed {
+		return `SELECT count(*), COALESCE(sum(range_size), 0)::INT8 FROM [SHOW RANGES FROM ` + target + ` WITH DETAILS]`
+	}
+
+	return `SELECT count(*), COALESCE(sum(range_size_mb) * 1000000, 0)::INT8 FROM [SHOW RANGES FROM ` + target + `]`
+}
+
+// readRangeStats returns the number of ranges of target and their size in
+// bytes, see rangeStatsStatement. The size counts whole ranges, including the
+// data of other objects sharing a range with target.
+func readRangeStats(ctx context.Context, conn *pgx.Conn, target string, detailed bool) (int64, int64, error) {
+	var count, size int64
+	err := conn.QueryRow(ctx, rangeStatsStatement(target, detailed)).Scan(&count, &size)
+
+	return count, size, err
+}
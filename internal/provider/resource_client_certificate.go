@@ -0,0 +1,439 @@
+package provider
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	certUsernameAttr        = "username"
+	certCaCertFileAttr      = "ca_cert_file"
+	certCaKeyFileAttr       = "ca_key_file"
+	certCaSecretAttr        = "ca_secret"
+	certKeyAlgorithmAttr    = "key_algorithm"
+	certRsaBitsAttr         = "rsa_bits"
+	certEcdsaCurveAttr      = "ecdsa_curve"
+	certValidityHoursAttr   = "validity_period_hours"
+	certEarlyRenewalAttr    = "early_renewal_hours"
+	certPrivateKeyPemAttr   = "private_key_pem"
+	certCertPemAttr         = "cert_pem"
+	certCaCertPemAttr       = "ca_cert_pem"
+	certSerialNumberAttr    = "serial_number"
+	certValidityStartAttr   = "validity_start_time"
+	certValidityEndAttr     = "validity_end_time"
+	certReadyForRenewalAttr = "ready_for_renewal"
+
+	caSecretNameAttr      = "name"
+	caSecretNamespaceAttr = "namespace"
+	caSecretCertKeyAttr   = "cert_key"
+	caSecretKeyKeyAttr    = "private_key_key"
+)
+
+var ecdsaCurves = map[string]elliptic.Curve{
+	"P256": elliptic.P256(),
+	"P384": elliptic.P384(),
+	"P521": elliptic.P521(),
+}
+
+func resourceClientCertificate() *schema.Resource {
+	return &schema.Resource{
+		// This description is used by the documentation generator and the language server.
+		Description: "Resource used to issue a client certificate for a user, signed by the CA of the Cockroachdb cluster. " +
+			"The certificate is replaced once it enters its early renewal window.",
+
+		CreateContext: resourceClientCertificateCreate,
+		ReadContext:   resourceClientCertificateRead,
+		UpdateContext: resourceClientCertificateUpdate,
+		DeleteContext: resourceClientCertificateDelete,
+		CustomizeDiff: resourceClientCertificateCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			certUsernameAttr: {
+				Description: "Name of the user, used as the common name of the certificate.",
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+			},
+			certCaCertFileAttr: {
+				Description:  "Path of the PEM encoded CA certificate.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{certCaCertFileAttr, certCaSecretAttr},
+				RequiredWith: []string{certCaKeyFileAttr},
+			},
+			certCaKeyFileAttr: {
+				Description:  "Path of the PEM encoded CA private key.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				RequiredWith: []string{certCaCertFileAttr},
+			},
+			certCaSecretAttr: {
+				Description: "Kubernetes Secret holding the CA key pair, read with the `kube_config` of the provider.",
+				Type:        schema.TypeList,
+				MaxItems:    1,
+				Optional:    true,
+				ForceNew:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						caSecretNameAttr: {
+							Description: "Name of the Secret.",
+							Type:        schema.TypeString,
+							Required:    true,
+							ForceNew:    true,
+						},
+						caSecretNamespaceAttr: {
+							Description: "Namespace of the Secret. (default is the namespace of the provider `kube_config`)",
+							Type:        schema.TypeString,
+							Optional:    true,
+							ForceNew:    true,
+							Default:     "",
+						},
+						caSecretCertKeyAttr: {
+							Description: "Key of the CA certificate in the Secret.",
+							Type:        schema.TypeString,
+							Optional:    true,
+							ForceNew:    true,
+							Default:     "ca.crt",
+						},
+						caSecretKeyKeyAttr: {
+							Description: "Key of the CA private key in the Secret.",
+							Type:        schema.TypeString,
+							Optional:    true,
+							ForceNew:    true,
+							Default:     "ca.key",
+						},
+					},
+				},
+			},
+			certKeyAlgorithmAttr: {
+				Description:  "Algorithm of the generated private key, `RSA` or `ECDSA`.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "RSA",
+				ValidateFunc: validation.StringInSlice([]string{"RSA", "ECDSA"}, false),
+			},
+			certRsaBitsAttr: {
+				Description:  "Size of the generated RSA key.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      2048,
+				ValidateFunc: validation.IntInSlice([]int{2048, 3072, 4096}),
+			},
+			certEcdsaCurveAttr: {
+				Description:  "Curve of the generated ECDSA key, `P256`, `P384` or `P521`.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "P256",
+				ValidateFunc: validation.StringInSlice([]string{"P256", "P384", "P521"}, false),
+			},
+			certValidityHoursAttr: {
+				Description:  "Number of hours the certificate is valid for, capped at the expiry of the CA certificate.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      8760,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			certEarlyRenewalAttr: {
+				Description:  "Number of hours before the expiry of the certificate when it is renewed.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      720,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			certPrivateKeyPemAttr: {
+				Description: "PEM encoded private key of the certificate.",
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+			},
+			certCertPemAttr: {
+				Description: "PEM encoded client certificate.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			certCaCertPemAttr: {
+				Description: "PEM encoded CA certificate which signed the client certificate.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			certSerialNumberAttr: {
+				Description: "Serial number of the certificate.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			certValidityStartAttr: {
+				Description: "Time the certificate becomes valid, in RFC 3339 format.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			certValidityEndAttr: {
+				Description: "Time the certificate expires, in RFC 3339 format.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			certReadyForRenewalAttr: {
+				Description: "True when the certificate is within its early renewal window, the certificate is then replaced on the next apply.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func resourceClientCertificateCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	username := d.Get(certUsernameAttr).(string)
+
+	if username == "" {
+		return diag.Errorf("username can't be an empty string")
+	}
+
+	var (
+		ca_cert_pem []byte
+		ca_key_pem  []byte
+	)
+
+	if secret := d.Get(certCaSecretAttr).([]interface{}); len(secret) > 0 {
+		ca_secret := secret[0].(map[string]interface{})
+
+		if cockroachClient.kubeConn.kubeClient == nil {
+			return diag.Errorf("%s requires the kube_config of the provider", certCaSecretAttr)
+		}
+
+		namespace := ca_secret[caSecretNamespaceAttr].(string)
+		if namespace == "" {
+			namespace = cockroachClient.kubeConn.nameSpace
+		}
+		name := ca_secret[caSecretNameAttr].(string)
+
+		s, err := cockroachClient.kubeConn.kubeClient.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return diag.Errorf("cannot read the CA secret %s/%s: %s", namespace, name, err)
+		}
+
+		var ok bool
+		if ca_cert_pem, ok = s.Data[ca_secret[caSecretCertKeyAttr].(string)]; !ok {
+			return diag.Errorf("secret %s/%s has no key %s", namespace, name, ca_secret[caSecretCertKeyAttr].(string))
+		}
+		if ca_key_pem, ok = s.Data[ca_secret[caSecretKeyKeyAttr].(string)]; !ok {
+			return diag.Errorf("secret %s/%s has no key %s", namespace, name, ca_secret[caSecretKeyKeyAttr].(string))
+		}
+	} else {
+		var err error
+		if ca_cert_pem, err = os.ReadFile(d.Get(certCaCertFileAttr).(string)); err != nil {
+			return diag.FromErr(err)
+		}
+		if ca_key_pem, err = os.ReadFile(d.Get(certCaKeyFileAttr).(string)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	ca_cert, err := parseCertificatePEM(ca_cert_pem)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	ca_key, err := parsePrivateKeyPEM(ca_key_pem)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	key, err := generatePrivateKey(d.Get(certKeyAlgorithmAttr).(string), d.Get(certRsaBitsAttr).(int), d.Get(certEcdsaCurveAttr).(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	validity := time.Duration(d.Get(certValidityHoursAttr).(int)) * time.Hour
+	cert, err := createClientCertificate(username, key, ca_cert, ca_key, time.Now(), validity)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	key_der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(cert.SerialNumber.String())
+	d.Set(certPrivateKeyPemAttr, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key_der})))
+	d.Set(certCertPemAttr, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
+	d.Set(certCaCertPemAttr, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca_cert.Raw})))
+	d.Set(certSerialNumberAttr, cert.SerialNumber.String())
+	d.Set(certValidityStartAttr, cert.NotBefore.UTC().Format(time.RFC3339))
+	d.Set(certValidityEndAttr, cert.NotAfter.UTC().Format(time.RFC3339))
+	d.Set(certReadyForRenewalAttr, false)
+
+	return diag.Diagnostics{}
+}
+
+func resourceClientCertificateRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// the certificate only lives in the state
+	return nil
+}
+
+func resourceClientCertificateUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// only early_renewal_hours can change in place, it is used by the plan
+	return nil
+}
+
+func resourceClientCertificateDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.SetId("")
+
+	return diag.Diagnostics{}
+}
+
+func resourceClientCertificateCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" {
+		return nil
+	}
+
+	ready, err := readyForRenewal(d.Get(certValidityEndAttr).(string), d.Get(certEarlyRenewalAttr).(int), time.Now())
+	if err != nil {
+		return err
+	}
+
+	if !ready {
+		return nil
+	}
+
+	if err := d.SetNew(certReadyForRenewalAttr, true); err != nil {
+		return err
+	}
+
+	return d.ForceNew(certReadyForRenewalAttr)
+}
+
+// readyForRenewal tells if a certificate expiring at validity_end is within
+// its early renewal window.
+func readyForRenewal(validity_end string, early_renewal_hours int, now time.Time) (bool, error) {
+	if validity_end == "" {
+		return false, nil
+	}
+
+	end, err := time.Parse(time.RFC3339, validity_end)
+	if err != nil {
+		return false, err
+	}
+
+	return !now.Before(end.Add(-time.Duration(early_renewal_hours) * time.Hour)), nil
+}
+
+func generatePrivateKey(algorithm string, rsa_bits int, ecdsa_curve string) (crypto.Signer, error) {
+	switch algorithm {
+	case "RSA":
+		return rsa.GenerateKey(rand.Reader, rsa_bits)
+	case "ECDSA":
+		curve, ok := ecdsaCurves[ecdsa_curve]
+		if !ok {
+			return nil, fmt.Errorf("unsupported ECDSA curve: %s", ecdsa_curve)
+		}
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	}
+
+	return nil, fmt.Errorf("unsupported key algorithm: %s", algorithm)
+}
+
+// createClientCertificate signs a client certificate for username, CockroachDB
+// maps the common name of the certificate to the user. The certificate never
+// outlives the CA which signs it.
+func createClientCertificate(username string, key crypto.Signer, ca_cert *x509.Certificate, ca_key crypto.Signer, now time.Time, validity time.Duration) (*x509.Certificate, error) {
+	if !now.Before(ca_cert.NotAfter) {
+		return nil, fmt.Errorf("CA certificate %s expired at %s", ca_cert.Subject.CommonName, ca_cert.NotAfter.Format(time.RFC3339))
+	}
+
+	not_after := now.Add(validity)
+	if not_after.After(ca_cert.NotAfter) {
+		not_after = ca_cert.NotAfter
+	}
+
+	// Key encipherment only applies to RSA key exchange, ECDSA and Ed25519
+	// keys only sign.
+	key_usage := x509.KeyUsageDigitalSignature
+	if _, ok := key.Public().(*rsa.PublicKey); ok {
+		key_usage |= x509.KeyUsageKeyEncipherment
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName: username,
+		},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              not_after,
+		KeyUsage:              key_usage,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca_cert, key.Public(), ca_key)
+	if err != nil {
+		return nil, err
+	}
+
+	return x509.ParseCertificate(der)
+}
+
+func parseCertificatePEM(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("CA certificate is not a PEM encoded certificate")
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// parsePrivateKeyPEM accepts PKCS#1, PKCS#8 and SEC 1 encoded keys.
+func parsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("CA private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse the CA private key: %s", err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported CA private key type %T", key)
+	}
+
+	return signer, nil
+}
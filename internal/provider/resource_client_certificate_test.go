@@ -0,0 +1,194 @@
+package provider
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccResourceClientCertificate(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceClientCertificate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"cockroach_client_certificate.foo", "ready_for_renewal", "false"),
+				),
+			},
+		},
+	})
+}
+
+const testAccResourceClientCertificate = `
+resource "cockroach_client_certificate" "foo" {
+  username = "bar"
+
+  ca_secret {
+    name = "cockroachdb-ca"
+  }
+}
+`
+
+func testCA(t *testing.T) (*x509.Certificate, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Cockroach CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert, key
+}
+
+func TestCreateClientCertificate(t *testing.T) {
+	ca_cert, ca_key := testCA(t)
+
+	for _, algorithm := range []string{"RSA", "ECDSA"} {
+		key, err := generatePrivateKey(algorithm, 2048, "P256")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cert, err := createClientCertificate("app", key, ca_cert, ca_key, time.Now(), 10*time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if cert.Subject.CommonName != "app" {
+			t.Errorf("expected common name app, got %s", cert.Subject.CommonName)
+		}
+
+		if len(cert.ExtKeyUsage) != 1 || cert.ExtKeyUsage[0] != x509.ExtKeyUsageClientAuth {
+			t.Errorf("expected a client auth certificate, got %v", cert.ExtKeyUsage)
+		}
+
+		if err := cert.CheckSignatureFrom(ca_cert); err != nil {
+			t.Errorf("expected the certificate to be signed by the CA: %s", err)
+		}
+
+		if _, ok := key.(*ecdsa.PrivateKey); ok != (algorithm == "ECDSA") {
+			t.Errorf("expected a %s key, got %T", algorithm, key)
+		}
+
+		if encipherment := cert.KeyUsage&x509.KeyUsageKeyEncipherment != 0; encipherment != (algorithm == "RSA") {
+			t.Errorf("expected key encipherment only for RSA keys, got %v for %s", cert.KeyUsage, algorithm)
+		}
+	}
+}
+
+func TestGeneratePrivateKeyCurves(t *testing.T) {
+	// Go's crypto/tls, which CockroachDB uses, does not negotiate P-224
+	if _, err := generatePrivateKey("ECDSA", 0, "P224"); err == nil {
+		t.Error("expected an error for the P224 curve")
+	}
+
+	for _, curve := range []string{"P256", "P384", "P521"} {
+		if _, err := generatePrivateKey("ECDSA", 0, curve); err != nil {
+			t.Errorf("cannot generate a %s key: %s", curve, err)
+		}
+	}
+}
+
+func TestCreateClientCertificateValidity(t *testing.T) {
+	ca_cert, ca_key := testCA(t)
+
+	key, err := generatePrivateKey("ECDSA", 0, "P256")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := createClientCertificate("app", key, ca_cert, ca_key, time.Now(), 365*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !cert.NotAfter.Equal(ca_cert.NotAfter) {
+		t.Errorf("expected the certificate to expire with the CA at %s, got %s", ca_cert.NotAfter, cert.NotAfter)
+	}
+
+	if _, err := createClientCertificate("app", key, ca_cert, ca_key, ca_cert.NotAfter.Add(time.Hour), time.Hour); err == nil {
+		t.Error("expected an error for an expired CA")
+	}
+}
+
+func TestParsePrivateKeyPEM(t *testing.T) {
+	_, ca_key := testCA(t)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(ca_key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocks := []*pem.Block{
+		{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(ca_key)},
+		{Type: "PRIVATE KEY", Bytes: pkcs8},
+	}
+
+	for _, block := range blocks {
+		key, err := parsePrivateKeyPEM(pem.EncodeToMemory(block))
+		if err != nil {
+			t.Fatalf("cannot parse %s: %s", block.Type, err)
+		}
+		if !ca_key.Equal(key) {
+			t.Errorf("%s key does not match", block.Type)
+		}
+	}
+
+	if _, err := parsePrivateKeyPEM([]byte("not a key")); err == nil {
+		t.Error("expected an error for a key which is not PEM encoded")
+	}
+}
+
+func TestReadyForRenewal(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		end      string
+		early    int
+		expected bool
+	}{
+		{"", 720, false},
+		{"2023-03-01T12:00:00Z", 720, false},
+		{"2022-03-20T12:00:00Z", 720, true},
+		{"2022-03-01T11:00:00Z", 0, true},
+	}
+
+	for _, c := range cases {
+		ready, err := readyForRenewal(c.end, c.early, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ready != c.expected {
+			t.Errorf("readyForRenewal(%q, %d) = %t, expected %t", c.end, c.early, ready, c.expected)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package provider
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/jackc/pgx/v4"
@@ -10,16 +11,23 @@ import (
 	"database/sql"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"strings"
 )
 
 const (
-	dbNameAttr          = "name"
-	dbOwnerAttr         = "owner"
-	dbOptionsAttr       = "options"
-	dbEncodingAttr      = "encoding"
-	dbPrimaryRegionAttr = "primary_region"
-	dbRegionsAttr       = "regions"
+	dbNameAttr               = "name"
+	dbOwnerAttr              = "owner"
+	dbOptionsAttr            = "options"
+	dbEncodingAttr           = "encoding"
+	dbPrimaryRegionAttr      = "primary_region"
+	dbRegionsAttr            = "regions"
+	dbSecondaryRegionAttr    = "secondary_region"
+	dbSurvivalGoalAttr       = "survival_goal"
+	dbPlacementAttr          = "placement"
+	dbSuperRegionsAttr       = "super_regions"
+	dbSuperRegionNameAttr    = "name"
+	dbSuperRegionRegionsAttr = "regions"
 )
 
 func resourceDatabase() *schema.Resource {
@@ -34,6 +42,7 @@ func resourceDatabase() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: resourceDatabaseImporter,
 		},
+		CustomizeDiff: resourceDatabaseCustomizeDiff,
 
 		Schema: map[string]*schema.Schema{
 			dbNameAttr: {
@@ -42,16 +51,18 @@ func resourceDatabase() *schema.Resource {
 				Required:    true,
 			},
 			dbOwnerAttr: {
-				Description: "Owner of the database.",
+				Description: "Owner of the database, the connecting user owns the database if not specified.",
 				Type:        schema.TypeString,
 				Optional:    true,
-				Default:     "",
+				Computed:    true,
 			},
 			dbEncodingAttr: {
-				Description: "Encoding to set to the database. (Optional argument, do not specify if not required)",
-				Type:        schema.TypeString,
-				Optional:    true,
-				Default:     "",
+				Description:      "Encoding to set to the database, CockroachDB only supports `UTF8`. (Optional argument, do not specify if not required)",
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressEncodingDiff,
 			},
 			dbPrimaryRegionAttr: {
 				Description: "Primary region of the database. (Optional argument, do not specify if not required)",
@@ -60,13 +71,56 @@ func resourceDatabase() *schema.Resource {
 				Default:     "",
 			},
 			dbRegionsAttr: {
-				Description: "Regions where the database is created. (Optional argument, do not specify if not required)",
-				Type:        schema.TypeList,
+				Description: "Regions of the database, listing the primary region again is optional, every region must be a region of the cluster. (Optional argument, do not specify if not required)",
+				Type:        schema.TypeSet,
 				Elem: &schema.Schema{
 					Type: schema.TypeString,
 				},
 				Optional: true,
 			},
+			dbSecondaryRegionAttr: {
+				Description: "Secondary region of the database, used as leaseholder fallback when the primary region fails. (Optional argument, do not specify if not required)",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+			},
+			dbSurvivalGoalAttr: {
+				Description:  "Survival goal of the database, one of `zone` or `region`. The `region` goal requires at least three regions. (Optional argument, do not specify if not required)",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.StringInSlice([]string{"zone", "region"}, false),
+			},
+			dbPlacementAttr: {
+				Description:  "Data placement policy of the database, one of `default` or `restricted`. (Optional argument, do not specify if not required)",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.StringInSlice([]string{"default", "restricted"}, false),
+			},
+			dbSuperRegionsAttr: {
+				Description: "Super regions of the database, every region of a super region must be a region of the database. (Optional argument, do not specify if not required)",
+				Type:        schema.TypeSet,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						dbSuperRegionNameAttr: {
+							Description: "Name of the super region.",
+							Type:        schema.TypeString,
+							Required:    true,
+						},
+						dbSuperRegionRegionsAttr: {
+							Description: "Regions that are part of the super region.",
+							Type:        schema.TypeSet,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+							Required: true,
+						},
+					},
+				},
+				Optional: true,
+				Computed: true,
+			},
 			argLocalPort: {
 				Description: "Local port to be used for port-forward. (default is 26258), use different port to avoid same port opening.",
 				Type:        schema.TypeString,
@@ -85,7 +139,11 @@ func resourceDatabaseCreate(ctx context.Context, d *schema.ResourceData, meta in
 	owner := d.Get(dbOwnerAttr).(string)
 	encoding := d.Get(dbEncodingAttr).(string)
 	primary_region := d.Get(dbPrimaryRegionAttr).(string)
-	regions := convertToString(d.Get(dbRegionsAttr).([]interface{}))
+	regions := convertToString(d.Get(dbRegionsAttr).(*schema.Set).List())
+	secondary_region := d.Get(dbSecondaryRegionAttr).(string)
+	survival_goal := d.Get(dbSurvivalGoalAttr).(string)
+	placement := d.Get(dbPlacementAttr).(string)
+	super_regions := expandSuperRegions(d.Get(dbSuperRegionsAttr).(*schema.Set).List())
 	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
 
 	// stopCh control the port forwarding lifecycle. When it gets closed the
@@ -110,8 +168,9 @@ func resourceDatabaseCreate(ctx context.Context, d *schema.ResourceData, meta in
 		set_primary_region = "PRIMARY REGION " + pq.QuoteIdentifier(primary_region)
 	}
 
-	if len(regions) != 0 {
-		set_regions = "REGIONS " + pq.QuoteIdentifier(strings.Join(regions, ""))
+	// the primary region may be listed in regions as well
+	if all_regions := databaseRegions(primary_region, regions); len(all_regions) > 1 {
+		set_regions = "REGIONS " + quoteIdentifiers(all_regions[1:])
 	}
 
 	tryPortForwardIfNeeded(ctx, d, meta, stopCh, readyCh, local_port)
@@ -140,14 +199,70 @@ func resourceDatabaseCreate(ctx context.Context, d *schema.ResourceData, meta in
 		return diag.FromErr(err)
 	}
 
-	_, err = conn.Exec(ctx,
-		`ALTER DATABASE `+
-			pq.QuoteIdentifier(name)+
-			` OWNER TO `+
-			pq.QuoteIdentifier(owner),
-	)
-	if err != nil {
-		return diag.FromErr(err)
+	if owner != "" {
+		_, err = conn.Exec(ctx,
+			`ALTER DATABASE `+
+				pq.QuoteIdentifier(name)+
+				` OWNER TO `+
+				pq.QuoteIdentifier(owner),
+		)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	// the secondary region, super regions and placement can only be set once
+	// the regions exist, the survival goal goes last as it depends on the
+	// number of regions
+	if secondary_region != "" {
+		_, err = conn.Exec(ctx,
+			`ALTER DATABASE `+
+				pq.QuoteIdentifier(name)+
+				` SET SECONDARY REGION `+
+				pq.QuoteIdentifier(secondary_region),
+		)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if len(super_regions) != 0 {
+		if _, err := conn.Exec(ctx, `SET enable_super_regions = 'on'`); err != nil {
+			return diag.FromErr(err)
+		}
+
+		for _, super_region := range super_regions {
+			_, err = conn.Exec(ctx,
+				`ALTER DATABASE `+
+					pq.QuoteIdentifier(name)+
+					` ADD SUPER REGION `+
+					pq.QuoteIdentifier(super_region.name)+
+					` VALUES `+
+					quoteIdentifiers(super_region.regions),
+			)
+			if err != nil {
+				return diag.FromErr(err)
+			}
+		}
+	}
+
+	if placement != "" {
+		if err := alterDatabasePlacement(ctx, conn, name, placement); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if survival_goal != "" {
+		_, err = conn.Exec(ctx,
+			`ALTER DATABASE `+
+				pq.QuoteIdentifier(name)+
+				` SURVIVE `+
+				strings.ToUpper(survival_goal)+
+				` FAILURE`,
+		)
+		if err != nil {
+			return diag.FromErr(err)
+		}
 	}
 
 	var id int
@@ -164,6 +279,9 @@ func resourceDatabaseCreate(ctx context.Context, d *schema.ResourceData, meta in
 	d.Set(dbEncodingAttr, encoding)
 	d.Set(dbPrimaryRegionAttr, primary_region)
 	d.Set(dbRegionsAttr, regions)
+	d.Set(dbSecondaryRegionAttr, secondary_region)
+	d.Set(dbSurvivalGoalAttr, survival_goal)
+	d.Set(dbPlacementAttr, placement)
 
 	close(stopCh)
 
@@ -194,69 +312,112 @@ func resourceDatabaseRead(ctx context.Context, d *schema.ResourceData, meta inte
 		return diag.FromErr(err)
 	}
 
-	name := d.Get(dbNameAttr).(string)
+	// the id survives renames, so the database is looked up by id and the
+	// name is read back
+	id := d.Id()
 
-	rows, err := conn.Query(ctx, "SELECT name AS database_name, owner, primary_region, regions, survival_goal FROM crdb_internal.databases")
+	var (
+		name           string
+		owner          string
+		primary_region sql.NullString
+		regions        []string
+		survival_goal  sql.NullString
+		encoding       string
+	)
+	err = conn.QueryRow(ctx,
+		`SELECT d.name, d.owner, d.primary_region, d.regions, d.survival_goal, pg_encoding_to_char(p.encoding)
+		FROM crdb_internal.databases AS d
+		JOIN pg_catalog.pg_database AS p ON p.datname = d.name
+		WHERE d.id = $1`,
+		id,
+	).Scan(&name, &owner, &primary_region, &regions, &survival_goal, &encoding)
+	if err == pgx.ErrNoRows {
+		logInfo("database with id %s not found, removing it from state", id)
+		d.SetId("")
+		close(stopCh)
+		return diag.Diagnostics{}
+	}
 	if err != nil {
-		// handle this error better than this
 		return diag.FromErr(err)
 	}
-	found := false
-	defer rows.Close()
 
-	// database_name |     owner     | primary_region | regions | survival_goal
-	for rows.Next() {
-		var (
-			database_name    string
-			owner            string
-			primary_region   string
-			primary_region_n sql.NullString
-			regions          []string
-			survival_goal    sql.NullString
-		)
-		err = rows.Scan(&database_name, &owner, &primary_region_n, &regions, &survival_goal)
+	// the primary region is tracked by its own attribute, it is only kept
+	// in regions when the configuration lists it there
+	listed_regions := convertToString(d.Get(dbRegionsAttr).(*schema.Set).List())
+	state_regions := stateRegions(primary_region.String, regions, listed_regions)
+
+	// secondary regions, placement policies and super regions only exist
+	// for multi-region databases of clusters running v22.1 or later
+	multi_region := false
+	if primary_region.String != "" {
+		multi_region, err = clusterVersionAtLeast(ctx, conn, "22.1")
 		if err != nil {
-			// handle this error
 			return diag.FromErr(err)
 		}
+	}
 
-		if primary_region_n.Valid {
-			primary_region = primary_region_n.String
+	var (
+		secondary_region sql.NullString
+		placement        sql.NullString
+		super_regions    []superRegion
+	)
+	if multi_region {
+		err = conn.QueryRow(ctx,
+			`SELECT secondary_region, placement_policy FROM crdb_internal.databases WHERE id = $1`,
+			id,
+		).Scan(&secondary_region, &placement)
+		if err != nil {
+			return diag.FromErr(err)
 		}
 
-		if database_name == name {
-			// TODO: find a way to read all the roles
-			// if err := d.Set(dbRolesAttr, options); err != nil {
-			// 	return diag.FromErr(err)
-			// }
+		super_regions, err = readSuperRegions(ctx, conn, name)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
 
-			if err := d.Set(dbOwnerAttr, owner); err != nil {
-				return diag.FromErr(err)
-			}
+	if err := d.Set(dbNameAttr, name); err != nil {
+		return diag.FromErr(err)
+	}
 
-			if err := d.Set(dbPrimaryRegionAttr, primary_region); err != nil {
-				return diag.FromErr(err)
-			}
+	if err := d.Set(dbOwnerAttr, owner); err != nil {
+		return diag.FromErr(err)
+	}
 
-			if err := d.Set(dbRegionsAttr, regions); err != nil {
-				return diag.FromErr(err)
-			}
+	if err := d.Set(dbEncodingAttr, encoding); err != nil {
+		return diag.FromErr(err)
+	}
 
-			found = true
-			break
-		}
+	if err := d.Set(dbPrimaryRegionAttr, primary_region.String); err != nil {
+		return diag.FromErr(err)
 	}
-	// get any error encountered during iteration
-	err = rows.Err()
-	if err != nil {
+
+	if err := d.Set(dbRegionsAttr, state_regions); err != nil {
 		return diag.FromErr(err)
 	}
 
-	close(stopCh)
-	if found == false {
-		return diag.Errorf("Cannot find database with name: " + name)
+	if err := d.Set(dbSecondaryRegionAttr, secondary_region.String); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set(dbSurvivalGoalAttr, strings.ToLower(survival_goal.String)); err != nil {
+		return diag.FromErr(err)
 	}
 
+	// placement and super regions are computed when they are not
+	// configured, so changes made outside Terraform are still detected
+	if multi_region {
+		if err := d.Set(dbPlacementAttr, strings.ToLower(placement.String)); err != nil {
+			return diag.FromErr(err)
+		}
+
+		if err := d.Set(dbSuperRegionsAttr, flattenSuperRegions(super_regions)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	close(stopCh)
+
 	return diag.Diagnostics{}
 }
 
@@ -322,36 +483,74 @@ func resourceDatabaseUpdate(ctx context.Context, d *schema.ResourceData, meta in
 		}
 	}
 
-	if d.HasChange(dbPrimaryRegionAttr) {
+	// lowering the survival goal must happen before regions are dropped
+	if d.HasChange(dbSurvivalGoalAttr) && d.Get(dbSurvivalGoalAttr).(string) == "zone" {
 		name := d.Get(dbNameAttr).(string)
-		_, nraw := d.GetChange(dbPrimaryRegionAttr)
-		// o := oraw.(string)
-		n := nraw.(string)
 
 		_, err = conn.Exec(ctx,
 			`ALTER DATABASE `+
 				pq.QuoteIdentifier(name)+
-				` SET PRIMARY REGION `+
-				pq.QuoteIdentifier(n),
+				` SURVIVE ZONE FAILURE`,
 		)
 		if err != nil {
 			return diag.FromErr(err)
 		}
 	}
 
-	if d.HasChange(dbRegionsAttr) {
+	// super regions are dropped or shrunk before the regions they use are
+	// dropped, CockroachDB refuses to drop a region of a super region
+	super_regions_after := []string{}
+	if d.HasChange(dbSuperRegionsAttr) {
 		name := d.Get(dbNameAttr).(string)
+		oprimary, _ := d.GetChange(dbPrimaryRegionAttr)
+		oregions, _ := d.GetChange(dbRegionsAttr)
+		oraw, nraw := d.GetChange(dbSuperRegionsAttr)
+
+		before, after := superRegionStatements(
+			name,
+			expandSuperRegions(oraw.(*schema.Set).List()),
+			expandSuperRegions(nraw.(*schema.Set).List()),
+			databaseRegions(oprimary.(string), convertToString(oregions.(*schema.Set).List())),
+		)
+		super_regions_after = after
+
+		if len(before) > 0 {
+			if err := execSuperRegionStatements(ctx, conn, before); err != nil {
+				return diag.FromErr(err)
+			}
+		}
+	}
+
+	if d.HasChange(dbPrimaryRegionAttr) || d.HasChange(dbRegionsAttr) || d.HasChange(dbSecondaryRegionAttr) {
+		name := d.Get(dbNameAttr).(string)
+		oprimary, nprimary := d.GetChange(dbPrimaryRegionAttr)
 		oraw, nraw := d.GetChange(dbRegionsAttr)
-		o := convertToString(oraw.([]interface{}))
-		n := convertToString(nraw.([]interface{}))
+		o := databaseRegions(oprimary.(string), convertToString(oraw.(*schema.Set).List()))
+		n := databaseRegions(nprimary.(string), convertToString(nraw.(*schema.Set).List()))
+
+		// a database without a primary region is not multi-region yet,
+		// setting the primary region is what enables adding regions
+		if oprimary.(string) == "" && nprimary.(string) != "" {
+			_, err = conn.Exec(ctx,
+				`ALTER DATABASE `+
+					pq.QuoteIdentifier(name)+
+					` SET PRIMARY REGION `+
+					pq.QuoteIdentifier(nprimary.(string)),
+			)
+			if err != nil {
+				return diag.FromErr(err)
+			}
+			o = append(o, nprimary.(string))
+		}
 
-		// drop unused regions
-		for _, region := range o {
-			if !contains(n, region) {
+		// create new regions, including the new primary region which must
+		// be part of the database before it can be promoted
+		for _, region := range n {
+			if !contains(o, region) {
 				_, err = conn.Exec(ctx,
 					`ALTER DATABASE `+
 						pq.QuoteIdentifier(name)+
-						` DROP REGION `+
+						` ADD REGION `+
 						pq.QuoteIdentifier(region),
 				)
 				if err != nil {
@@ -360,13 +559,49 @@ func resourceDatabaseUpdate(ctx context.Context, d *schema.ResourceData, meta in
 			}
 		}
 
-		// create new regions
-		for _, region := range n {
-			if !contains(o, region) {
+		if oprimary.(string) != "" && nprimary.(string) != "" && oprimary.(string) != nprimary.(string) {
+			_, err = conn.Exec(ctx,
+				`ALTER DATABASE `+
+					pq.QuoteIdentifier(name)+
+					` SET PRIMARY REGION `+
+					pq.QuoteIdentifier(nprimary.(string)),
+			)
+			if err != nil {
+				return diag.FromErr(err)
+			}
+		}
+
+		// the secondary region has to move before its region is dropped
+		if d.HasChange(dbSecondaryRegionAttr) {
+			secondary_region := d.Get(dbSecondaryRegionAttr).(string)
+
+			if secondary_region == "" {
 				_, err = conn.Exec(ctx,
 					`ALTER DATABASE `+
 						pq.QuoteIdentifier(name)+
-						` ADD REGION `+
+						` DROP SECONDARY REGION`,
+				)
+			} else {
+				_, err = conn.Exec(ctx,
+					`ALTER DATABASE `+
+						pq.QuoteIdentifier(name)+
+						` SET SECONDARY REGION `+
+						pq.QuoteIdentifier(secondary_region),
+				)
+			}
+			if err != nil {
+				return diag.FromErr(err)
+			}
+		}
+
+		// drop unused regions, the primary region can only be dropped once
+		// it is the last region of the database
+		for _, region := range o {
+			if !contains(n, region) && region != oprimary.(string) {
+				_, err = conn.Exec(ctx,
+					`ALTER DATABASE `+
+						pq.QuoteIdentifier(name)+
+						` DROP REGION `+
 						pq.QuoteIdentifier(region),
 				)
 				if err != nil {
@@ -374,6 +609,50 @@ func resourceDatabaseUpdate(ctx context.Context, d *schema.ResourceData, meta in
 				}
 			}
 		}
+
+		if oprimary.(string) != "" && !contains(n, oprimary.(string)) {
+			_, err = conn.Exec(ctx,
+				`ALTER DATABASE `+
+					pq.QuoteIdentifier(name)+
+					` DROP REGION `+
+					pq.QuoteIdentifier(oprimary.(string)),
+			)
+			if err != nil {
+				return diag.FromErr(err)
+			}
+		}
+	}
+
+	// super regions which only need new regions are created or grown once
+	// the regions exist
+	if len(super_regions_after) > 0 {
+		if err := execSuperRegionStatements(ctx, conn, super_regions_after); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	// placement is computed when it is not configured, the policy is only
+	// changed when the configuration sets it
+	if d.HasChange(dbPlacementAttr) && d.Get(dbPlacementAttr).(string) != "" {
+		name := d.Get(dbNameAttr).(string)
+
+		if err := alterDatabasePlacement(ctx, conn, name, d.Get(dbPlacementAttr).(string)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	// raising the survival goal must happen once all the regions exist
+	if d.HasChange(dbSurvivalGoalAttr) && d.Get(dbSurvivalGoalAttr).(string) == "region" {
+		name := d.Get(dbNameAttr).(string)
+
+		_, err = conn.Exec(ctx,
+			`ALTER DATABASE `+
+				pq.QuoteIdentifier(name)+
+				` SURVIVE REGION FAILURE`,
+		)
+		if err != nil {
+			return diag.FromErr(err)
+		}
 	}
 
 	d.Partial(false)
@@ -381,6 +660,301 @@ func resourceDatabaseUpdate(ctx context.Context, d *schema.ResourceData, meta in
 	return diag.Diagnostics{}
 }
 
+// suppressEncodingDiff ignores the spelling differences of an encoding, for
+// example utf-8 and UTF8.
+func suppressEncodingDiff(k, old, new string, d *schema.ResourceData) bool {
+	normalize := func(encoding string) string {
+		return strings.ToUpper(strings.NewReplacer("-", "", "_", "").Replace(encoding))
+	}
+
+	return normalize(old) == normalize(new)
+}
+
+func resourceDatabaseCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if !d.HasChange(dbPrimaryRegionAttr) && !d.HasChange(dbRegionsAttr) && !d.HasChange(dbSecondaryRegionAttr) {
+		return nil
+	}
+
+	// regions computed from other resources can only be validated on apply
+	if !d.NewValueKnown(dbPrimaryRegionAttr) || !d.NewValueKnown(dbRegionsAttr) || !d.NewValueKnown(dbSecondaryRegionAttr) {
+		return nil
+	}
+
+	primary_region := d.Get(dbPrimaryRegionAttr).(string)
+	regions := convertToString(d.Get(dbRegionsAttr).(*schema.Set).List())
+	secondary_region := d.Get(dbSecondaryRegionAttr).(string)
+
+	if primary_region == "" && len(regions) != 0 {
+		oprimary, _ := d.GetChange(dbPrimaryRegionAttr)
+		if oprimary.(string) != "" {
+			return fmt.Errorf("primary region %s can't be dropped while the database has other regions, move it with %s first", oprimary.(string), dbPrimaryRegionAttr)
+		}
+		return fmt.Errorf("%s requires %s to be set", dbRegionsAttr, dbPrimaryRegionAttr)
+	}
+
+	if secondary_region != "" && secondary_region != primary_region && !contains(regions, secondary_region) {
+		return fmt.Errorf("secondary region %s must be one of the database regions", secondary_region)
+	}
+
+	wanted := databaseRegions(primary_region, regions)
+	if len(wanted) == 0 {
+		return nil
+	}
+
+	cockroachClient := meta.(*cockroachClient)
+
+	local_port := d.Get(argLocalPort).(string)
+	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
+
+	// stopCh control the port forwarding lifecycle. When it gets closed the
+	// port forward will terminate
+	stopCh := make(chan struct{}, 1)
+	// readyCh communicate when the port forward is ready to get traffic
+	readyCh := make(chan struct{})
+
+	// the diff is computed for every plan, the port forward and the
+	// connection are released on every path
+	defer close(stopCh)
+
+	if diags := tryPortForwardIfNeeded(ctx, nil, meta, stopCh, readyCh, local_port); diags.HasError() {
+		return fmt.Errorf("failed to port-forward to cockroachdb: %s", diags[0].Summary)
+	}
+
+	conn, err := pgx.Connect(ctx, dns)
+
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	cluster_regions, err := readClusterRegions(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	for _, region := range wanted {
+		if !contains(cluster_regions, region) {
+			return fmt.Errorf("region %s is not a region of the cluster, available regions: %s", region, strings.Join(cluster_regions, ", "))
+		}
+	}
+
+	return nil
+}
+
+// databaseRegions returns every region of a database, the primary region first.
+func databaseRegions(primary_region string, regions []string) []string {
+	all := []string{}
+	if primary_region != "" {
+		all = append(all, primary_region)
+	}
+	for _, region := range regions {
+		if !contains(all, region) {
+			all = append(all, region)
+		}
+	}
+
+	return all
+}
+
+// stateRegions returns the regions of a database as they are kept in the
+// state, the primary region is only included when it is listed in the
+// configuration.
+func stateRegions(primary_region string, regions []string, listed []string) []string {
+	keep_primary := contains(listed, primary_region)
+
+	state_regions := []string{}
+	for _, region := range regions {
+		if region != primary_region || keep_primary {
+			state_regions = append(state_regions, region)
+		}
+	}
+
+	return state_regions
+}
+
+func readClusterRegions(ctx context.Context, conn *pgx.Conn) ([]string, error) {
+	rows, err := conn.Query(ctx, `SELECT region FROM [SHOW REGIONS FROM CLUSTER]`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	regions := []string{}
+	for rows.Next() {
+		var region string
+		if err := rows.Scan(&region); err != nil {
+			return nil, err
+		}
+		regions = append(regions, region)
+	}
+
+	return regions, rows.Err()
+}
+
+type superRegion struct {
+	name    string
+	regions []string
+}
+
+// superRegionStatements plans the changes of the super regions of a database
+// around the changes of its regions. The statements of before run while
+// existing holds the regions of the database, before regions are added or
+// dropped, the statements of after run once the new regions exist.
+func superRegionStatements(name string, o []superRegion, n []superRegion, existing []string) ([]string, []string) {
+	before := []string{}
+	after := []string{}
+
+	statement := func(action string, super_region string, regions []string) string {
+		statement := `ALTER DATABASE ` + pq.QuoteIdentifier(name) + ` ` + action + ` SUPER REGION ` + pq.QuoteIdentifier(super_region)
+		if len(regions) > 0 {
+			statement += ` VALUES ` + quoteIdentifiers(regions)
+		}
+		return statement
+	}
+
+	for _, super_region := range o {
+		if findSuperRegion(n, super_region.name) == nil {
+			before = append(before, statement("DROP", super_region.name, nil))
+		}
+	}
+
+	for _, super_region := range n {
+		old := findSuperRegion(o, super_region.name)
+		if old == nil {
+			after = append(after, statement("ADD", super_region.name, super_region.regions))
+			continue
+		}
+
+		if sameElements(old.regions, super_region.regions) {
+			continue
+		}
+
+		// every region of the new super region already exists
+		if subsetOf(super_region.regions, existing) {
+			before = append(before, statement("ALTER", super_region.name, super_region.regions))
+			continue
+		}
+
+		kept := []string{}
+		for _, region := range old.regions {
+			if contains(super_region.regions, region) {
+				kept = append(kept, region)
+			}
+		}
+
+		switch {
+		case len(kept) == len(old.regions):
+			// the super region only grows
+		case len(kept) > 0:
+			before = append(before, statement("ALTER", super_region.name, kept))
+		default:
+			before = append(before, statement("DROP", super_region.name, nil))
+			after = append(after, statement("ADD", super_region.name, super_region.regions))
+			continue
+		}
+		after = append(after, statement("ALTER", super_region.name, super_region.regions))
+	}
+
+	return before, after
+}
+
+// execSuperRegionStatements runs statements of superRegionStatements, super
+// regions are still gated behind a session variable.
+func execSuperRegionStatements(ctx context.Context, conn *pgx.Conn, statements []string) error {
+	if _, err := conn.Exec(ctx, `SET enable_super_regions = 'on'`); err != nil {
+		return err
+	}
+
+	for _, statement := range statements {
+		if _, err := conn.Exec(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func expandSuperRegions(raw_list []interface{}) []superRegion {
+	super_regions := make([]superRegion, 0, len(raw_list))
+	for _, raw := range raw_list {
+		m := raw.(map[string]interface{})
+		super_regions = append(super_regions, superRegion{
+			name:    m[dbSuperRegionNameAttr].(string),
+			regions: convertToString(m[dbSuperRegionRegionsAttr].(*schema.Set).List()),
+		})
+	}
+
+	return super_regions
+}
+
+func flattenSuperRegions(super_regions []superRegion) []interface{} {
+	raw_list := make([]interface{}, 0, len(super_regions))
+	for _, super_region := range super_regions {
+		raw_list = append(raw_list, map[string]interface{}{
+			dbSuperRegionNameAttr:    super_region.name,
+			dbSuperRegionRegionsAttr: super_region.regions,
+		})
+	}
+
+	return raw_list
+}
+
+func findSuperRegion(super_regions []superRegion, name string) *superRegion {
+	for i := range super_regions {
+		if super_regions[i].name == name {
+			return &super_regions[i]
+		}
+	}
+	return nil
+}
+
+// clusterVersionAtLeast tells if the active version of the cluster is at
+// least the given version, such as 22.1.
+func clusterVersionAtLeast(ctx context.Context, conn *pgx.Conn, version string) (bool, error) {
+	var at_least bool
+	err := conn.QueryRow(ctx, `SELECT crdb_internal.is_at_least_version($1)`, version).Scan(&at_least)
+
+	return at_least, err
+}
+
+func readSuperRegions(ctx context.Context, conn *pgx.Conn, name string) ([]superRegion, error) {
+	rows, err := conn.Query(ctx,
+		`SELECT super_region_name, regions FROM [SHOW SUPER REGIONS FROM DATABASE `+
+			pq.QuoteIdentifier(name)+
+			`] ORDER BY super_region_name`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	super_regions := []superRegion{}
+	for rows.Next() {
+		var super_region superRegion
+		if err := rows.Scan(&super_region.name, &super_region.regions); err != nil {
+			return nil, err
+		}
+		super_regions = append(super_regions, super_region)
+	}
+
+	return super_regions, rows.Err()
+}
+
+func alterDatabasePlacement(ctx context.Context, conn *pgx.Conn, name string, placement string) error {
+	// placement policies are still gated behind a session variable
+	if _, err := conn.Exec(ctx, `SET enable_multiregion_placement_policy = 'on'`); err != nil {
+		return err
+	}
+
+	_, err := conn.Exec(ctx,
+		`ALTER DATABASE `+
+			pq.QuoteIdentifier(name)+
+			` PLACEMENT `+
+			strings.ToUpper(placement),
+	)
+	return err
+}
+
 func resourceDatabaseDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	cockroachClient := meta.(*cockroachClient)
 
@@ -425,10 +999,19 @@ func resourceDatabaseDelete(ctx context.Context, d *schema.ResourceData, meta in
 func resourceDatabaseImporter(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
 	cockroachClient := meta.(*cockroachClient)
 
+	// defaults are not applied to imported resources, the following read
+	// needs the port as well
 	local_port := d.Get(argLocalPort).(string)
+	if local_port == "" {
+		local_port = "26258"
+		if err := d.Set(argLocalPort, local_port); err != nil {
+			return nil, err
+		}
+	}
 	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
 
-	// id is the name of the database from the cockroachdb
+	// id is the name of the database from the cockroachdb, the read which
+	// follows the import fills in the rest of the attributes
 	name := d.Id()
 
 	// stopCh control the port forwarding lifecycle. When it gets closed the
@@ -1,10 +1,12 @@
 package provider
 
 import (
+	"reflect"
 	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func TestAccResourceDatabase(t *testing.T) {
@@ -30,3 +32,105 @@ resource "cockroach_database" "foo" {
   name = "bar"
 }
 `
+
+func TestDatabaseRegions(t *testing.T) {
+	regions := databaseRegions("us-east1", []string{"us-west1", "us-east1", "europe-west1"})
+	expected := []string{"us-east1", "us-west1", "europe-west1"}
+
+	if len(regions) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, regions)
+	}
+	for i := range expected {
+		if regions[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, regions)
+		}
+	}
+
+	if regions := databaseRegions("", nil); len(regions) != 0 {
+		t.Fatalf("expected no regions, got %v", regions)
+	}
+}
+
+func TestSuppressEncodingDiff(t *testing.T) {
+	if !suppressEncodingDiff(dbEncodingAttr, "UTF8", "utf-8", nil) {
+		t.Error("expected UTF8 and utf-8 to be the same encoding")
+	}
+
+	if suppressEncodingDiff(dbEncodingAttr, "UTF8", "latin1", nil) {
+		t.Error("expected UTF8 and latin1 to be different encodings")
+	}
+}
+
+func TestExpandSuperRegions(t *testing.T) {
+	raw := schema.NewSet(schema.HashResource(resourceDatabase().Schema[dbSuperRegionsAttr].Elem.(*schema.Resource)), []interface{}{
+		map[string]interface{}{
+			dbSuperRegionNameAttr:    "us",
+			dbSuperRegionRegionsAttr: schema.NewSet(schema.HashString, []interface{}{"us-west1", "us-east1"}),
+		},
+	})
+
+	super_regions := expandSuperRegions(raw.List())
+	if len(super_regions) != 1 || super_regions[0].name != "us" {
+		t.Fatalf("unexpected super regions %v", super_regions)
+	}
+
+	// regions are compared as sets, the order read back from the cluster
+	// does not matter
+	if !sameElements(super_regions[0].regions, []string{"us-east1", "us-west1"}) {
+		t.Errorf("unexpected regions %v", super_regions[0].regions)
+	}
+}
+
+func TestStateRegions(t *testing.T) {
+	regions := []string{"us-east1", "us-west1", "europe-west1"}
+
+	if got := stateRegions("us-east1", regions, []string{"us-west1"}); !reflect.DeepEqual(got, []string{"us-west1", "europe-west1"}) {
+		t.Errorf("expected the primary region to be left out, got %v", got)
+	}
+
+	// configurations written before the primary region was left out of
+	// regions keep it there
+	if got := stateRegions("us-east1", regions, []string{"us-east1", "us-west1"}); !reflect.DeepEqual(got, regions) {
+		t.Errorf("expected the primary region to be kept, got %v", got)
+	}
+}
+
+func TestSuperRegionStatements(t *testing.T) {
+	o := []superRegion{
+		{name: "us", regions: []string{"us-east1", "us-west1"}},
+		{name: "eu", regions: []string{"europe-west1"}},
+		{name: "asia", regions: []string{"asia-east1"}},
+	}
+	n := []superRegion{
+		{name: "us", regions: []string{"us-east1", "us-central1"}},
+		{name: "asia", regions: []string{"asia-southeast1"}},
+		{name: "ap", regions: []string{"australia-southeast1"}},
+	}
+	existing := []string{"us-east1", "us-west1", "europe-west1", "asia-east1"}
+
+	before, after := superRegionStatements("orders", o, n, existing)
+
+	expected_before := []string{
+		`ALTER DATABASE "orders" DROP SUPER REGION "eu"`,
+		`ALTER DATABASE "orders" ALTER SUPER REGION "us" VALUES "us-east1"`,
+		`ALTER DATABASE "orders" DROP SUPER REGION "asia"`,
+	}
+	if !reflect.DeepEqual(before, expected_before) {
+		t.Errorf("expected %v before the regions change, got %v", expected_before, before)
+	}
+
+	expected_after := []string{
+		`ALTER DATABASE "orders" ALTER SUPER REGION "us" VALUES "us-east1", "us-central1"`,
+		`ALTER DATABASE "orders" ADD SUPER REGION "asia" VALUES "asia-southeast1"`,
+		`ALTER DATABASE "orders" ADD SUPER REGION "ap" VALUES "australia-southeast1"`,
+	}
+	if !reflect.DeepEqual(after, expected_after) {
+		t.Errorf("expected %v after the regions change, got %v", expected_after, after)
+	}
+
+	// shrinking to existing regions happens before regions are dropped
+	before, after = superRegionStatements("orders", o[:1], []superRegion{{name: "us", regions: []string{"us-east1"}}}, existing)
+	if len(before) != 1 || len(after) != 0 {
+		t.Errorf("expected a single statement before the regions change, got %v and %v", before, after)
+	}
+}
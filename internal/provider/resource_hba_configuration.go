@@ -0,0 +1,468 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/jackc/pgx/v4"
+	"github.com/lib/pq"
+)
+
+const (
+	hbaRuleAttr = "rule"
+
+	hbaTypeAttr     = "type"
+	hbaDatabaseAttr = "database"
+	hbaUserAttr     = "user"
+	hbaAddressAttr  = "address"
+	hbaMethodAttr   = "method"
+	hbaOptionsAttr  = "options"
+
+	hbaSetting = "server.host_based_authentication.configuration"
+)
+
+var (
+	hbaConnectionTypes = []string{"local", "host", "hostssl", "hostnossl"}
+	hbaMethods         = []string{"cert", "password", "cert-password", "scram-sha-256", "cert-scram-sha-256", "trust", "reject", "gss", "ldap"}
+)
+
+// hbaRule is a line of the host-based authentication configuration.
+type hbaRule struct {
+	connType string
+	database string
+	user     string
+	address  string
+	method   string
+	options  map[string]string
+}
+
+func resourceHbaConfiguration() *schema.Resource {
+	return &schema.Resource{
+		// This description is used by the documentation generator and the language server.
+		Description: "Resource used to manage the host-based authentication configuration of the cluster, the `" + hbaSetting + "` cluster setting. " +
+			"Rules are evaluated in order, destroying the resource resets the setting.",
+
+		CreateContext: resourceHbaConfigurationCreate,
+		ReadContext:   resourceHbaConfigurationRead,
+		UpdateContext: resourceHbaConfigurationUpdate,
+		DeleteContext: resourceHbaConfigurationDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceHbaConfigurationImporter,
+		},
+		CustomizeDiff: resourceHbaConfigurationCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			hbaRuleAttr: {
+				Description: "Ordered authentication rules, the first rule matching a connection decides how it authenticates.",
+				Type:        schema.TypeList,
+				Required:    true,
+				MinItems:    1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						hbaTypeAttr: {
+							Description:  "Connection type, one of `local`, `host`, `hostssl` or `hostnossl`.",
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice(hbaConnectionTypes, false),
+						},
+						hbaDatabaseAttr: {
+							Description: "Comma separated databases the rule applies to.",
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "all",
+						},
+						hbaUserAttr: {
+							Description: "Comma separated users the rule applies to.",
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "all",
+						},
+						hbaAddressAttr: {
+							Description: "Client address in CIDR notation, or `all`. Required except for `local` rules.",
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "",
+						},
+						hbaMethodAttr: {
+							Description:  "Authentication method, for example `cert`, `password`, `cert-password` or `reject`.",
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice(hbaMethods, false),
+						},
+						hbaOptionsAttr: {
+							Description: "Options of the authentication method, such as `ldapserver` for `ldap`.",
+							Type:        schema.TypeMap,
+							Optional:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+			argLocalPort: {
+				Description: "Local port to be used for port-forward. (default is 26264), use different port to avoid same port opening.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "26264",
+			},
+		},
+	}
+}
+
+func resourceHbaConfigurationCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	configuration, err := hbaConfiguration(expandHbaRules(d.Get(hbaRuleAttr).([]interface{})))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if diags := setHbaConfiguration(ctx, d, meta, configuration); diags.HasError() {
+		return diags
+	}
+
+	d.SetId(hbaSetting)
+
+	return resourceHbaConfigurationRead(ctx, d, meta)
+}
+
+func resourceHbaConfigurationRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	local_port := d.Get(argLocalPort).(string)
+	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
+
+	// stopCh control the port forwarding lifecycle. When it gets closed the
+	// port forward will terminate
+	stopCh := make(chan struct{}, 1)
+	// readyCh communicate when the port forward is ready to get traffic
+	readyCh := make(chan struct{})
+
+	tryPortForwardIfNeeded(ctx, d, meta, stopCh, readyCh, local_port)
+
+	conn, err := pgx.Connect(ctx, dns)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := conn.Ping(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+
+	var configuration string
+	err = conn.QueryRow(ctx, `SHOW CLUSTER SETTING `+hbaSetting).Scan(&configuration)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	rules, err := parseHbaConfiguration(configuration)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set(hbaRuleAttr, flattenHbaRules(rules)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	close(stopCh)
+
+	return diag.Diagnostics{}
+}
+
+func resourceHbaConfigurationUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if d.HasChange(hbaRuleAttr) {
+		configuration, err := hbaConfiguration(expandHbaRules(d.Get(hbaRuleAttr).([]interface{})))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		if diags := setHbaConfiguration(ctx, d, meta, configuration); diags.HasError() {
+			return diags
+		}
+	}
+
+	return resourceHbaConfigurationRead(ctx, d, meta)
+}
+
+func resourceHbaConfigurationDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// an empty configuration resets the setting to the default rules
+	if diags := setHbaConfiguration(ctx, d, meta, ""); diags.HasError() {
+		return diags
+	}
+
+	d.SetId("")
+
+	return diag.Diagnostics{}
+}
+
+func resourceHbaConfigurationImporter(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	// there is a single configuration per cluster, the id is the name of the
+	// setting
+	d.SetId(hbaSetting)
+
+	if err := d.Set(argLocalPort, "26264"); err != nil {
+		return nil, err
+	}
+
+	if diags := resourceHbaConfigurationRead(ctx, d, meta); diags.HasError() {
+		return nil, fmt.Errorf("unable to import hba configuration: %s", diags[0].Summary)
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceHbaConfigurationCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	// rules built from other resources are validated on apply
+	if !d.NewValueKnown(hbaRuleAttr) {
+		return nil
+	}
+
+	_, err := hbaConfiguration(expandHbaRules(d.Get(hbaRuleAttr).([]interface{})))
+
+	return err
+}
+
+func setHbaConfiguration(ctx context.Context, d *schema.ResourceData, meta interface{}, configuration string) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	local_port := d.Get(argLocalPort).(string)
+	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
+
+	// stopCh control the port forwarding lifecycle. When it gets closed the
+	// port forward will terminate
+	stopCh := make(chan struct{}, 1)
+	// readyCh communicate when the port forward is ready to get traffic
+	readyCh := make(chan struct{})
+
+	tryPortForwardIfNeeded(ctx, d, meta, stopCh, readyCh, local_port)
+
+	conn, err := pgx.Connect(ctx, dns)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := conn.Ping(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if configuration == "" {
+		_, err = conn.Exec(ctx, `RESET CLUSTER SETTING `+hbaSetting)
+	} else {
+		_, err = conn.Exec(ctx, `SET CLUSTER SETTING `+hbaSetting+` = `+pq.QuoteLiteral(configuration))
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	close(stopCh)
+
+	return diag.Diagnostics{}
+}
+
+// hbaConfiguration validates the rules and renders them into the value of the
+// cluster setting.
+func hbaConfiguration(rules []hbaRule) (string, error) {
+	for i, rule := range rules {
+		if err := validateHbaRule(rule); err != nil {
+			return "", fmt.Errorf("rule %d: %s", i, err)
+		}
+	}
+
+	return renderHbaConfiguration(rules), nil
+}
+
+func validateHbaRule(rule hbaRule) error {
+	if rule.connType == "local" {
+		if rule.address != "" {
+			return fmt.Errorf("local rules don't have an address")
+		}
+		return nil
+	}
+
+	if rule.address == "" {
+		return fmt.Errorf("address is required for %s rules", rule.connType)
+	}
+
+	if rule.address == "all" {
+		return nil
+	}
+
+	if _, _, err := net.ParseCIDR(rule.address); err != nil {
+		return fmt.Errorf("address %s is not `all` or a CIDR: %s", rule.address, err)
+	}
+
+	return nil
+}
+
+func expandHbaRules(raw []interface{}) []hbaRule {
+	rules := make([]hbaRule, 0, len(raw))
+	for _, r := range raw {
+		m := r.(map[string]interface{})
+		rules = append(rules, hbaRule{
+			connType: m[hbaTypeAttr].(string),
+			database: m[hbaDatabaseAttr].(string),
+			user:     m[hbaUserAttr].(string),
+			address:  m[hbaAddressAttr].(string),
+			method:   m[hbaMethodAttr].(string),
+			options:  expandStringMap(m[hbaOptionsAttr].(map[string]interface{})),
+		})
+	}
+
+	return rules
+}
+
+func flattenHbaRules(rules []hbaRule) []interface{} {
+	raw := make([]interface{}, 0, len(rules))
+	for _, rule := range rules {
+		options := map[string]interface{}{}
+		for k, v := range rule.options {
+			options[k] = v
+		}
+
+		raw = append(raw, map[string]interface{}{
+			hbaTypeAttr:     rule.connType,
+			hbaDatabaseAttr: rule.database,
+			hbaUserAttr:     rule.user,
+			hbaAddressAttr:  rule.address,
+			hbaMethodAttr:   rule.method,
+			hbaOptionsAttr:  options,
+		})
+	}
+
+	return raw
+}
+
+// renderHbaConfiguration writes the rules in the pg_hba.conf format of the
+// cluster setting, one rule per line.
+func renderHbaConfiguration(rules []hbaRule) string {
+	lines := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		fields := []string{rule.connType, rule.database, rule.user}
+		if rule.connType != "local" {
+			fields = append(fields, rule.address)
+		}
+		fields = append(fields, rule.method)
+
+		names := make([]string, 0, len(rule.options))
+		for name := range rule.options {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			fields = append(fields, name+"="+quoteHbaValue(rule.options[name]))
+		}
+
+		lines = append(lines, strings.Join(fields, " "))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// parseHbaConfiguration reads the rules of the cluster setting, comments and
+// empty lines are skipped.
+func parseHbaConfiguration(configuration string) ([]hbaRule, error) {
+	rules := []hbaRule{}
+	for _, line := range strings.Split(configuration, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := splitHbaFields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		rule := hbaRule{connType: fields[0]}
+
+		min_fields := 5
+		if rule.connType == "local" {
+			min_fields = 4
+		}
+		if len(fields) < min_fields {
+			return nil, fmt.Errorf("cannot parse hba rule: %s", strings.TrimSpace(line))
+		}
+
+		rule.database = fields[1]
+		rule.user = fields[2]
+		fields = fields[3:]
+
+		if rule.connType != "local" {
+			rule.address = fields[0]
+			fields = fields[1:]
+		}
+
+		// an address followed by a netmask
+		if rule.connType != "local" && len(fields) > 1 {
+			if mask := net.ParseIP(fields[0]); mask != nil {
+				if mask4 := mask.To4(); mask4 != nil {
+					mask = mask4
+				}
+				ones, _ := net.IPMask(mask).Size()
+				rule.address = fmt.Sprintf("%s/%d", rule.address, ones)
+				fields = fields[1:]
+			}
+		}
+
+		rule.method = fields[0]
+
+		for _, option := range fields[1:] {
+			parts := strings.SplitN(option, "=", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("cannot parse option %s of hba rule: %s", option, strings.TrimSpace(line))
+			}
+			if rule.options == nil {
+				rule.options = map[string]string{}
+			}
+			rule.options[parts[0]] = strings.Trim(parts[1], `"`)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// splitHbaFields splits a line on whitespace, double quoted fields may
+// contain whitespace.
+func splitHbaFields(line string) []string {
+	var (
+		fields []string
+		field  strings.Builder
+		quoted bool
+	)
+
+	for _, c := range line {
+		switch {
+		case c == '"':
+			quoted = !quoted
+			field.WriteRune(c)
+		case !quoted && (c == ' ' || c == '\t' || c == '\r'):
+			if field.Len() > 0 {
+				fields = append(fields, field.String())
+				field.Reset()
+			}
+		default:
+			field.WriteRune(c)
+		}
+	}
+
+	if field.Len() > 0 {
+		fields = append(fields, field.String())
+	}
+
+	return fields
+}
+
+func quoteHbaValue(value string) string {
+	if strings.ContainsAny(value, " \t,") {
+		return `"` + value + `"`
+	}
+
+	return value
+}
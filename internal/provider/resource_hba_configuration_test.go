@@ -0,0 +1,122 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccResourceHbaConfiguration(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceHbaConfiguration,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"cockroach_hba_configuration.foo", "rule.0.method", "cert"),
+				),
+			},
+		},
+	})
+}
+
+const testAccResourceHbaConfiguration = `
+resource "cockroach_hba_configuration" "foo" {
+  rule {
+    type    = "hostssl"
+    user    = "bar"
+    address = "all"
+    method  = "cert"
+  }
+
+  rule {
+    type    = "host"
+    address = "0.0.0.0/0"
+    method  = "password"
+  }
+}
+`
+
+func TestRenderHbaConfiguration(t *testing.T) {
+	rules := []hbaRule{
+		{connType: "hostssl", database: "all", user: "app", address: "10.0.0.0/8", method: "cert"},
+		{connType: "local", database: "all", user: "all", method: "password"},
+		{connType: "host", database: "all", user: "all", address: "all", method: "ldap", options: map[string]string{
+			"ldapserver":     "ldap.example.com",
+			"ldapsearchbase": "ou=users, dc=example, dc=com",
+		}},
+	}
+
+	expected := "hostssl all app 10.0.0.0/8 cert\n" +
+		"local all all password\n" +
+		`host all all all ldap ldapsearchbase="ou=users, dc=example, dc=com" ldapserver=ldap.example.com`
+
+	configuration := renderHbaConfiguration(rules)
+	if configuration != expected {
+		t.Errorf("expected configuration:\n%s\ngot:\n%s", expected, configuration)
+	}
+
+	parsed, err := parseHbaConfiguration(configuration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(parsed, rules) {
+		t.Errorf("expected the rules to survive a round trip, got %v", parsed)
+	}
+}
+
+func TestParseHbaConfiguration(t *testing.T) {
+	configuration := `
+# TYPE   DATABASE USER ADDRESS      METHOD
+host     all      root 10.0.0.0 255.0.0.0 cert-password # admins
+hostssl  all      all  all          cert
+`
+
+	rules, err := parseHbaConfiguration(configuration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []hbaRule{
+		{connType: "host", database: "all", user: "root", address: "10.0.0.0/8", method: "cert-password"},
+		{connType: "hostssl", database: "all", user: "all", address: "all", method: "cert"},
+	}
+
+	if !reflect.DeepEqual(rules, expected) {
+		t.Errorf("expected %v, got %v", expected, rules)
+	}
+
+	if _, err := parseHbaConfiguration("host all all"); err == nil {
+		t.Error("expected an error for an incomplete rule")
+	}
+}
+
+func TestValidateHbaRule(t *testing.T) {
+	valid := []hbaRule{
+		{connType: "local", method: "password"},
+		{connType: "host", address: "all", method: "cert"},
+		{connType: "hostssl", address: "2001:db8::/32", method: "cert"},
+	}
+
+	for _, rule := range valid {
+		if err := validateHbaRule(rule); err != nil {
+			t.Errorf("expected %v to be valid, got %s", rule, err)
+		}
+	}
+
+	invalid := []hbaRule{
+		{connType: "local", address: "all", method: "password"},
+		{connType: "host", method: "cert"},
+		{connType: "host", address: "10.0.0.1", method: "cert"},
+	}
+
+	for _, rule := range invalid {
+		if err := validateHbaRule(rule); err == nil {
+			t.Errorf("expected %v to be invalid", rule)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/jackc/pgx/v4"
+	"github.com/lib/pq"
+)
+
+const (
+	sessionRoleAttr     = "role"
+	sessionDatabaseAttr = "database"
+	sessionSettingsAttr = "settings"
+)
+
+var sessionVariableRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_.]*$`)
+
+// sessionListVariables take a comma separated list of values.
+var sessionListVariables = []string{"search_path"}
+
+func resourceSessionSettings() *schema.Resource {
+	return &schema.Resource{
+		// This description is used by the documentation generator and the language server.
+		Description: "Resource used to set the default session variables of a role, a database, or a role inside a database. " +
+			"Variables removed from `settings` are reset, destroying the resource resets all of them.",
+
+		CreateContext: resourceSessionSettingsCreate,
+		ReadContext:   resourceSessionSettingsRead,
+		UpdateContext: resourceSessionSettingsUpdate,
+		DeleteContext: resourceSessionSettingsDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceSessionSettingsImporter,
+		},
+
+		Schema: map[string]*schema.Schema{
+			sessionRoleAttr: {
+				Description: "Name of the role, the settings apply to all roles when not specified.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     "",
+			},
+			sessionDatabaseAttr: {
+				Description: "Name of the database, the settings apply to all databases when not specified.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     "",
+			},
+			sessionSettingsAttr: {
+				Description:  "Session variables and their default values, for example `statement_timeout = \"10s\"`. The value of a list variable such as `search_path` is a comma separated list, for example `\"app, public\"`.",
+				Type:         schema.TypeMap,
+				Required:     true,
+				Elem:         &schema.Schema{Type: schema.TypeString},
+				ValidateFunc: validateSessionVariables,
+			},
+			argLocalPort: {
+				Description: "Local port to be used for port-forward. (default is 26263), use different port to avoid same port opening.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "26263",
+			},
+		},
+	}
+}
+
+func resourceSessionSettingsCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	role := d.Get(sessionRoleAttr).(string)
+	database := d.Get(sessionDatabaseAttr).(string)
+
+	settings := expandStringMap(d.Get(sessionSettingsAttr).(map[string]interface{}))
+
+	if diags := applySessionSettings(ctx, d, meta, settings, nil); diags.HasError() {
+		return diags
+	}
+
+	d.SetId(role + "/" + database)
+
+	return resourceSessionSettingsRead(ctx, d, meta)
+}
+
+func resourceSessionSettingsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	local_port := d.Get(argLocalPort).(string)
+	role := d.Get(sessionRoleAttr).(string)
+	database := d.Get(sessionDatabaseAttr).(string)
+	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
+
+	// stopCh control the port forwarding lifecycle. When it gets closed the
+	// port forward will terminate
+	stopCh := make(chan struct{}, 1)
+	// readyCh communicate when the port forward is ready to get traffic
+	readyCh := make(chan struct{})
+
+	tryPortForwardIfNeeded(ctx, d, meta, stopCh, readyCh, local_port)
+
+	conn, err := pgx.Connect(ctx, dns)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := conn.Ping(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// settings of all databases are stored with database id 0
+	database_id := 0
+	if database != "" {
+		err = conn.QueryRow(ctx, `SELECT id FROM crdb_internal.databases WHERE name = $1`, database).Scan(&database_id)
+		if err == pgx.ErrNoRows {
+			logInfo("database %s not found, removing session settings from state", database)
+			d.SetId("")
+			close(stopCh)
+			return diag.Diagnostics{}
+		}
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if role != "" {
+		var exists bool
+		err = conn.QueryRow(ctx, `SELECT count(*) > 0 FROM pg_catalog.pg_roles WHERE rolname = $1`, role).Scan(&exists)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		if !exists {
+			logInfo("role %s not found, removing session settings from state", role)
+			d.SetId("")
+			close(stopCh)
+			return diag.Diagnostics{}
+		}
+	}
+
+	var raw_settings []string
+	err = conn.QueryRow(ctx,
+		`SELECT settings FROM system.database_role_settings WHERE database_id::INT8 = $1 AND role_name = $2`,
+		database_id, role,
+	).Scan(&raw_settings)
+	if err != nil && err != pgx.ErrNoRows {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set(sessionSettingsAttr, parseSessionSettings(raw_settings)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	close(stopCh)
+
+	return diag.Diagnostics{}
+}
+
+func resourceSessionSettingsUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if d.HasChange(sessionSettingsAttr) {
+		old_settings, new_settings := d.GetChange(sessionSettingsAttr)
+
+		settings := expandStringMap(new_settings.(map[string]interface{}))
+
+		var removed []string
+		for name := range old_settings.(map[string]interface{}) {
+			if _, ok := settings[name]; !ok {
+				removed = append(removed, name)
+			}
+		}
+
+		if diags := applySessionSettings(ctx, d, meta, settings, removed); diags.HasError() {
+			return diags
+		}
+	}
+
+	return resourceSessionSettingsRead(ctx, d, meta)
+}
+
+func resourceSessionSettingsDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	local_port := d.Get(argLocalPort).(string)
+	role := d.Get(sessionRoleAttr).(string)
+	database := d.Get(sessionDatabaseAttr).(string)
+	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
+
+	// stopCh control the port forwarding lifecycle. When it gets closed the
+	// port forward will terminate
+	stopCh := make(chan struct{}, 1)
+	// readyCh communicate when the port forward is ready to get traffic
+	readyCh := make(chan struct{})
+
+	tryPortForwardIfNeeded(ctx, d, meta, stopCh, readyCh, local_port)
+
+	conn, err := pgx.Connect(ctx, dns)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := conn.Ping(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+
+	_, err = conn.Exec(ctx, sessionSettingsTarget(role, database)+` RESET ALL`)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+
+	close(stopCh)
+
+	return diag.Diagnostics{}
+}
+
+func resourceSessionSettingsImporter(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	// id has the form <role>/<database>, either part can be empty
+	parts := strings.SplitN(d.Id(), "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("unexpected import id %q, expected <role>/<database>", d.Id())
+	}
+
+	if err := d.Set(sessionRoleAttr, parts[0]); err != nil {
+		return nil, err
+	}
+
+	if err := d.Set(sessionDatabaseAttr, parts[1]); err != nil {
+		return nil, err
+	}
+
+	if err := d.Set(argLocalPort, "26263"); err != nil {
+		return nil, err
+	}
+
+	if diags := resourceSessionSettingsRead(ctx, d, meta); diags.HasError() {
+		return nil, fmt.Errorf("unable to import session settings: %s", diags[0].Summary)
+	}
+
+	if d.Id() == "" {
+		return nil, fmt.Errorf("cannot find role or database of session settings with id: %s", strings.Join(parts, "/"))
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// applySessionSettings sets the given session variables and resets the
+// removed ones.
+func applySessionSettings(ctx context.Context, d *schema.ResourceData, meta interface{}, settings map[string]string, removed []string) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	local_port := d.Get(argLocalPort).(string)
+	role := d.Get(sessionRoleAttr).(string)
+	database := d.Get(sessionDatabaseAttr).(string)
+	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
+
+	// stopCh control the port forwarding lifecycle. When it gets closed the
+	// port forward will terminate
+	stopCh := make(chan struct{}, 1)
+	// readyCh communicate when the port forward is ready to get traffic
+	readyCh := make(chan struct{})
+
+	tryPortForwardIfNeeded(ctx, d, meta, stopCh, readyCh, local_port)
+
+	conn, err := pgx.Connect(ctx, dns)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := conn.Ping(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+
+	target := sessionSettingsTarget(role, database)
+
+	for _, name := range removed {
+		if _, err := conn.Exec(ctx, target+` RESET `+name); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, err := conn.Exec(ctx, target+` SET `+name+` = `+sessionSettingValue(name, settings[name])); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	close(stopCh)
+
+	return diag.Diagnostics{}
+}
+
+func validateSessionVariables(v interface{}, k string) ([]string, []error) {
+	for name := range v.(map[string]interface{}) {
+		if !sessionVariableRegexp.MatchString(name) {
+			return nil, []error{fmt.Errorf("%s: invalid session variable name %q", k, name)}
+		}
+	}
+
+	return nil, nil
+}
+
+// sessionSettingsTarget returns the ALTER statement prefix for the settings
+// of a role and database pair, empty names stand for all of them.
+func sessionSettingsTarget(role string, database string) string {
+	switch {
+	case role == "" && database == "":
+		return `ALTER ROLE ALL`
+	case role == "":
+		return `ALTER DATABASE ` + pq.QuoteIdentifier(database)
+	case database == "":
+		return `ALTER ROLE ` + pq.QuoteIdentifier(role)
+	}
+
+	return `ALTER ROLE ` + pq.QuoteIdentifier(role) + ` IN DATABASE ` + pq.QuoteIdentifier(database)
+}
+
+// sessionSettingValue quotes the value of a session variable, every element
+// of a list variable is quoted on its own so search_path = "app, public" sets
+// two schemas instead of a single one named "app, public".
+func sessionSettingValue(name string, value string) string {
+	if !contains(sessionListVariables, name) {
+		return pq.QuoteLiteral(value)
+	}
+
+	elements := strings.Split(value, ",")
+	for i, element := range elements {
+		elements[i] = pq.QuoteLiteral(unquoteIdentifier(strings.TrimSpace(element)))
+	}
+
+	return strings.Join(elements, ", ")
+}
+
+// parseSessionSettings reads the name=value entries stored in
+// system.database_role_settings.
+func parseSessionSettings(raw []string) map[string]string {
+	settings := make(map[string]string, len(raw))
+	for _, setting := range raw {
+		parts := strings.SplitN(setting, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		settings[parts[0]] = parts[1]
+	}
+
+	return settings
+}
@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccResourceSessionSettings(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceSessionSettings,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"cockroach_session_settings.foo", "settings.statement_timeout", "10s"),
+				),
+			},
+		},
+	})
+}
+
+const testAccResourceSessionSettings = `
+resource "cockroach_session_settings" "foo" {
+  role     = "bar"
+  database = "defaultdb"
+
+  settings = {
+    statement_timeout = "10s"
+  }
+}
+`
+
+func TestSessionSettingsTarget(t *testing.T) {
+	cases := []struct {
+		role     string
+		database string
+		expected string
+	}{
+		{"", "", `ALTER ROLE ALL`},
+		{"", "orders", `ALTER DATABASE "orders"`},
+		{"app", "", `ALTER ROLE "app"`},
+		{"app", "orders", `ALTER ROLE "app" IN DATABASE "orders"`},
+	}
+
+	for _, c := range cases {
+		if target := sessionSettingsTarget(c.role, c.database); target != c.expected {
+			t.Errorf("sessionSettingsTarget(%q, %q) = %s, expected %s", c.role, c.database, target, c.expected)
+		}
+	}
+}
+
+func TestSessionSettingValue(t *testing.T) {
+	cases := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"statement_timeout", "10s", `'10s'`},
+		{"application_name", "it's, mine", `'it''s, mine'`},
+		{"search_path", "app, public", `'app', 'public'`},
+		{"search_path", `"$user",public`, `'$user', 'public'`},
+	}
+
+	for _, c := range cases {
+		if value := sessionSettingValue(c.name, c.value); value != c.expected {
+			t.Errorf("sessionSettingValue(%q, %q) = %s, expected %s", c.name, c.value, value, c.expected)
+		}
+	}
+}
+
+func TestParseSessionSettings(t *testing.T) {
+	settings := parseSessionSettings([]string{
+		"statement_timeout=10s",
+		"search_path=public, app",
+		"application_name=a=b",
+	})
+
+	expected := map[string]string{
+		"statement_timeout": "10s",
+		"search_path":       "public, app",
+		"application_name":  "a=b",
+	}
+
+	if !reflect.DeepEqual(settings, expected) {
+		t.Errorf("expected %v, got %v", expected, settings)
+	}
+}
+
+func TestValidateSessionVariables(t *testing.T) {
+	if _, errs := validateSessionVariables(map[string]interface{}{"statement_timeout": "10s"}, sessionSettingsAttr); len(errs) != 0 {
+		t.Errorf("expected statement_timeout to be valid, got %v", errs)
+	}
+
+	if _, errs := validateSessionVariables(map[string]interface{}{"timeout; DROP TABLE users": "1"}, sessionSettingsAttr); len(errs) == 0 {
+		t.Error("expected an invalid session variable name to be rejected")
+	}
+}
@@ -0,0 +1,389 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/jackc/pgx/v4"
+	"github.com/lib/pq"
+)
+
+const (
+	tableDatabaseAttr = "database"
+	tableSchemaAttr   = "schema"
+	tableNameAttr     = "table"
+	tableLocalityAttr = "locality"
+	tableRegionAttr   = "region"
+	tableColumnAttr   = "column"
+
+	localityGlobal          = "global"
+	localityRegionalByTable = "regional_by_table"
+	localityRegionalByRow   = "regional_by_row"
+)
+
+func resourceTableLocality() *schema.Resource {
+	return &schema.Resource{
+		// This description is used by the documentation generator and the language server.
+		Description: "Resource used to set the locality of an existing table of a multi-region database. " +
+			"Destroying the resource sets the table back to `REGIONAL BY TABLE IN PRIMARY REGION`.",
+
+		CreateContext: resourceTableLocalityCreate,
+		ReadContext:   resourceTableLocalityRead,
+		UpdateContext: resourceTableLocalityUpdate,
+		DeleteContext: resourceTableLocalityDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceTableLocalityImporter,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(20 * time.Minute),
+			Update: schema.DefaultTimeout(20 * time.Minute),
+			Delete: schema.DefaultTimeout(20 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			tableDatabaseAttr: {
+				Description: "Name of the database of the table.",
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+			},
+			tableSchemaAttr: {
+				Description: "Name of the schema of the table.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     "public",
+			},
+			tableNameAttr: {
+				Description: "Name of the table.",
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+			},
+			tableLocalityAttr: {
+				Description:  "Locality of the table, one of `global`, `regional_by_table` or `regional_by_row`.",
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice([]string{localityGlobal, localityRegionalByTable, localityRegionalByRow}, false),
+			},
+			tableRegionAttr: {
+				Description: "Home region of a `regional_by_table` table, the primary region of the database is used if not specified.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+			},
+			tableColumnAttr: {
+				Description: "Region column of a `regional_by_row` table, the hidden `crdb_region` column is used if not specified.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+			},
+			argLocalPort: {
+				Description: "Local port to be used for port-forward. (default is 26262), use different port to avoid same port opening.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "26262",
+			},
+		},
+	}
+}
+
+func resourceTableLocalityCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	database := d.Get(tableDatabaseAttr).(string)
+	schema_name := d.Get(tableSchemaAttr).(string)
+	table := d.Get(tableNameAttr).(string)
+
+	if database == "" || schema_name == "" || table == "" {
+		return diag.Errorf("database, schema and table can't be empty strings")
+	}
+
+	if diags := setTableLocality(ctx, d, meta, d.Timeout(schema.TimeoutCreate)); diags.HasError() {
+		return diags
+	}
+
+	d.SetId(database + "/" + schema_name + "/" + table)
+
+	return resourceTableLocalityRead(ctx, d, meta)
+}
+
+func resourceTableLocalityRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	local_port := d.Get(argLocalPort).(string)
+	database := d.Get(tableDatabaseAttr).(string)
+	schema_name := d.Get(tableSchemaAttr).(string)
+	table := d.Get(tableNameAttr).(string)
+	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
+
+	// stopCh control the port forwarding lifecycle. When it gets closed the
+	// port forward will terminate
+	stopCh := make(chan struct{}, 1)
+	// readyCh communicate when the port forward is ready to get traffic
+	readyCh := make(chan struct{})
+
+	tryPortForwardIfNeeded(ctx, d, meta, stopCh, readyCh, local_port)
+
+	conn, err := pgx.Connect(ctx, dns)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := conn.Ping(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+
+	var table_locality sql.NullString
+	err = conn.QueryRow(ctx,
+		`SELECT locality FROM crdb_internal.tables WHERE database_name = $1 AND schema_name = $2 AND name = $3 AND drop_time IS NULL`,
+		database, schema_name, table,
+	).Scan(&table_locality)
+	if err == pgx.ErrNoRows {
+		logInfo("table %s.%s.%s not found, removing it from state", database, schema_name, table)
+		d.SetId("")
+		close(stopCh)
+		return diag.Diagnostics{}
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	locality, region, column := parseTableLocality(table_locality.String)
+
+	if err := d.Set(tableLocalityAttr, locality); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set(tableRegionAttr, region); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set(tableColumnAttr, column); err != nil {
+		return diag.FromErr(err)
+	}
+
+	close(stopCh)
+
+	return diag.Diagnostics{}
+}
+
+func resourceTableLocalityUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if d.HasChange(tableLocalityAttr) || d.HasChange(tableRegionAttr) || d.HasChange(tableColumnAttr) {
+		if diags := setTableLocality(ctx, d, meta, d.Timeout(schema.TimeoutUpdate)); diags.HasError() {
+			return diags
+		}
+	}
+
+	return resourceTableLocalityRead(ctx, d, meta)
+}
+
+func resourceTableLocalityDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if err := d.Set(tableLocalityAttr, localityRegionalByTable); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set(tableRegionAttr, ""); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set(tableColumnAttr, ""); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if diags := setTableLocality(ctx, d, meta, d.Timeout(schema.TimeoutDelete)); diags.HasError() {
+		return diags
+	}
+
+	d.SetId("")
+
+	return diag.Diagnostics{}
+}
+
+func resourceTableLocalityImporter(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	// id has the form <database>/<schema>/<table>
+	parts := strings.SplitN(d.Id(), "/", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("unexpected import id %q, expected <database>/<schema>/<table>", d.Id())
+	}
+
+	if err := d.Set(tableDatabaseAttr, parts[0]); err != nil {
+		return nil, err
+	}
+
+	if err := d.Set(tableSchemaAttr, parts[1]); err != nil {
+		return nil, err
+	}
+
+	if err := d.Set(tableNameAttr, parts[2]); err != nil {
+		return nil, err
+	}
+
+	if err := d.Set(argLocalPort, "26262"); err != nil {
+		return nil, err
+	}
+
+	if diags := resourceTableLocalityRead(ctx, d, meta); diags.HasError() {
+		return nil, fmt.Errorf("unable to import table locality: %s", diags[0].Summary)
+	}
+
+	if d.Id() == "" {
+		return nil, fmt.Errorf("cannot find table with id: %s", strings.Join(parts, "/"))
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// setTableLocality changes the locality of the table and waits for the
+// schema change job it starts to finish.
+func setTableLocality(ctx context.Context, d *schema.ResourceData, meta interface{}, timeout time.Duration) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	local_port := d.Get(argLocalPort).(string)
+	database := d.Get(tableDatabaseAttr).(string)
+	schema_name := d.Get(tableSchemaAttr).(string)
+	table := d.Get(tableNameAttr).(string)
+	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
+
+	locality, err := tableLocalityClause(
+		d.Get(tableLocalityAttr).(string),
+		d.Get(tableRegionAttr).(string),
+		d.Get(tableColumnAttr).(string),
+	)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// stopCh control the port forwarding lifecycle. When it gets closed the
+	// port forward will terminate
+	stopCh := make(chan struct{}, 1)
+	// readyCh communicate when the port forward is ready to get traffic
+	readyCh := make(chan struct{})
+
+	tryPortForwardIfNeeded(ctx, d, meta, stopCh, readyCh, local_port)
+
+	conn, err := pgx.Connect(ctx, dns)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := conn.Ping(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+
+	var (
+		table_id int64
+		started  time.Time
+	)
+	err = conn.QueryRow(ctx,
+		`SELECT table_id, now()::TIMESTAMP FROM crdb_internal.tables WHERE database_name = $1 AND schema_name = $2 AND name = $3 AND drop_time IS NULL`,
+		database, schema_name, table,
+	).Scan(&table_id, &started)
+	if err == pgx.ErrNoRows {
+		return diag.Errorf("Cannot find table %s.%s.%s", database, schema_name, table)
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	_, err = conn.Exec(ctx,
+		`ALTER TABLE `+
+			pq.QuoteIdentifier(database)+
+			`.`+
+			pq.QuoteIdentifier(schema_name)+
+			`.`+
+			pq.QuoteIdentifier(table)+
+			` SET LOCALITY `+
+			locality,
+	)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// the locality change runs as an asynchronous schema change job
+	err = resource.RetryContext(ctx, timeout, func() *resource.RetryError {
+		var (
+			running int
+			failure sql.NullString
+		)
+		err := conn.QueryRow(ctx,
+			`SELECT count(*) FILTER (WHERE status NOT IN ('succeeded', 'failed', 'canceled')), max(error) FILTER (WHERE status IN ('failed', 'canceled'))
+			FROM crdb_internal.jobs
+			WHERE job_type IN ('SCHEMA CHANGE', 'NEW SCHEMA CHANGE') AND $1 = ANY(descriptor_ids) AND created >= $2`,
+			table_id, started,
+		).Scan(&running, &failure)
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
+
+		if failure.Valid {
+			return resource.NonRetryableError(fmt.Errorf("schema change of table %s.%s.%s failed: %s", database, schema_name, table, failure.String))
+		}
+
+		if running > 0 {
+			return resource.RetryableError(fmt.Errorf("waiting for the schema change of table %s.%s.%s", database, schema_name, table))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	close(stopCh)
+
+	return diag.Diagnostics{}
+}
+
+// tableLocalityClause builds the locality part of ALTER TABLE ... SET LOCALITY.
+func tableLocalityClause(locality string, region string, column string) (string, error) {
+	if region != "" && locality != localityRegionalByTable {
+		return "", fmt.Errorf("%s can only be set for %s tables", tableRegionAttr, localityRegionalByTable)
+	}
+
+	if column != "" && locality != localityRegionalByRow {
+		return "", fmt.Errorf("%s can only be set for %s tables", tableColumnAttr, localityRegionalByRow)
+	}
+
+	switch locality {
+	case localityGlobal:
+		return "GLOBAL", nil
+	case localityRegionalByTable:
+		if region == "" {
+			return "REGIONAL BY TABLE IN PRIMARY REGION", nil
+		}
+		return "REGIONAL BY TABLE IN " + pq.QuoteIdentifier(region), nil
+	case localityRegionalByRow:
+		if column == "" {
+			return "REGIONAL BY ROW", nil
+		}
+		return "REGIONAL BY ROW AS " + pq.QuoteIdentifier(column), nil
+	}
+
+	return "", fmt.Errorf("unknown locality: %s", locality)
+}
+
+// parseTableLocality parses the locality column of crdb_internal.tables, a
+// table without locality is homed in the primary region of the database.
+func parseTableLocality(locality string) (string, string, string) {
+	switch {
+	case locality == "GLOBAL":
+		return localityGlobal, "", ""
+	case strings.HasPrefix(locality, "REGIONAL BY ROW AS "):
+		return localityRegionalByRow, "", unquoteIdentifier(strings.TrimPrefix(locality, "REGIONAL BY ROW AS "))
+	case locality == "REGIONAL BY ROW":
+		return localityRegionalByRow, "", ""
+	case strings.HasPrefix(locality, "REGIONAL BY TABLE IN ") && locality != "REGIONAL BY TABLE IN PRIMARY REGION":
+		return localityRegionalByTable, unquoteIdentifier(strings.TrimPrefix(locality, "REGIONAL BY TABLE IN ")), ""
+	}
+
+	return localityRegionalByTable, "", ""
+}
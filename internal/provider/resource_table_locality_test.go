@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccResourceTableLocality(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceTableLocality,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"cockroach_table_locality.foo", "locality", "global"),
+				),
+			},
+		},
+	})
+}
+
+func TestParseTableLocality(t *testing.T) {
+	cases := []struct {
+		raw      string
+		locality string
+		region   string
+		column   string
+	}{
+		{"", localityRegionalByTable, "", ""},
+		{"GLOBAL", localityGlobal, "", ""},
+		{"REGIONAL BY TABLE IN PRIMARY REGION", localityRegionalByTable, "", ""},
+		{`REGIONAL BY TABLE IN "us-east1"`, localityRegionalByTable, "us-east1", ""},
+		{"REGIONAL BY ROW", localityRegionalByRow, "", ""},
+		{`REGIONAL BY ROW AS "home region"`, localityRegionalByRow, "", "home region"},
+		{"REGIONAL BY ROW AS region", localityRegionalByRow, "", "region"},
+	}
+
+	for _, c := range cases {
+		locality, region, column := parseTableLocality(c.raw)
+		if locality != c.locality || region != c.region || column != c.column {
+			t.Errorf("parseTableLocality(%q) = %q, %q, %q, expected %q, %q, %q",
+				c.raw, locality, region, column, c.locality, c.region, c.column)
+		}
+	}
+}
+
+func TestTableLocalityClause(t *testing.T) {
+	clause, err := tableLocalityClause(localityRegionalByTable, "us-east1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clause != `REGIONAL BY TABLE IN "us-east1"` {
+		t.Errorf("unexpected clause: %s", clause)
+	}
+
+	if _, err := tableLocalityClause(localityGlobal, "us-east1", ""); err == nil {
+		t.Error("expected an error when setting a region on a global table")
+	}
+
+	if _, err := tableLocalityClause(localityRegionalByTable, "", "region"); err == nil {
+		t.Error("expected an error when setting a column on a regional by table table")
+	}
+}
+
+const testAccResourceTableLocality = `
+resource "cockroach_table_locality" "foo" {
+  database = "test"
+  table = "accounts"
+  locality = "global"
+  local_port = "23457"
+}
+`
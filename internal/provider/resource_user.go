@@ -5,21 +5,99 @@ import (
 	"github.com/lib/pq"
 
 	"context"
+	"crypto/rand"
+	"database/sql"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"math/big"
+	"net"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
-	dbUsernameAttr = "username"
-	dbPasswordAttr = "password"
-	dbRolesAttr    = "roles"
-	dbAdminAttr    = "is_admin"
+	dbUsernameAttr     = "username"
+	dbPasswordAttr     = "password"
+	dbPasswordHashAttr = "password_hash"
+	dbRolesAttr        = "roles"
+	dbAdminAttr        = "is_admin"
+
+	dbGeneratePasswordAttr    = "generate_password"
+	dbGeneratedPasswordAttr   = "generated_password"
+	dbRotationTriggerAttr     = "rotation_trigger"
+	dbRotateAfterAttr         = "rotate_after"
+	dbRotationGracePeriodAttr = "rotation_grace_period"
+	dbLastRotatedAttr         = "last_rotated"
+	dbPreviousUsernameAttr    = "previous_username"
+
+	dbValidUntilAttr      = "valid_until"
+	dbConnectionLimitAttr = "connection_limit"
+
+	dbReassignOwnedToAttr  = "reassign_owned_to"
+	dbDropOwnedAttr        = "drop_owned"
+	dbRevokePrivilegesAttr = "revoke_privileges"
+
+	dbConnectionParametersAttr = "connection_parameters"
+	dbConnectionUriAttr        = "connection_uri"
+	dbJdbcUrlAttr              = "jdbc_url"
+
+	connectionDatabaseAttr    = "database"
+	connectionHostAttr        = "host"
+	connectionPortAttr        = "port"
+	connectionSslModeAttr     = "sslmode"
+	connectionSslRootCertAttr = "sslrootcert"
+	connectionSslCertAttr     = "sslcert"
+	connectionSslKeyAttr      = "sslkey"
+
+	passwordLengthAttr          = "length"
+	passwordUpperAttr           = "upper"
+	passwordLowerAttr           = "lower"
+	passwordNumericAttr         = "numeric"
+	passwordSpecialAttr         = "special"
+	passwordOverrideSpecialAttr = "override_special"
+)
+
+// prefixes of the password hashes CockroachDB accepts instead of a password
+var passwordHashPrefixes = []string{"SCRAM-SHA-256$", "CRDB-BCRYPT$"}
+
+// roleOption is a boolean role option of CREATE/ALTER USER, system.role_options
+// only stores the keyword which differs from the default.
+type roleOption struct {
+	attr         string
+	keyword      string
+	negation     string
+	defaultValue bool
+	description  string
+}
+
+var roleOptions = []roleOption{
+	{"login", "LOGIN", "NOLOGIN", true, "Allow the user to log in with any protocol."},
+	{"sqllogin", "SQLLOGIN", "NOSQLLOGIN", true, "Allow the user to log in with SQL clients."},
+	{"createdb", "CREATEDB", "NOCREATEDB", false, "Allow the user to create and rename databases."},
+	{"createrole", "CREATEROLE", "NOCREATEROLE", false, "Allow the user to create, alter and drop non-admin roles."},
+	{"createlogin", "CREATELOGIN", "NOCREATELOGIN", false, "Allow the user to manage the login options of other roles."},
+	{"controljob", "CONTROLJOB", "NOCONTROLJOB", false, "Allow the user to pause, resume and cancel jobs."},
+	{"controlchangefeed", "CONTROLCHANGEFEED", "NOCONTROLCHANGEFEED", false, "Allow the user to create changefeeds."},
+	{"viewactivity", "VIEWACTIVITY", "NOVIEWACTIVITY", false, "Allow the user to see the queries and sessions of other users."},
+	{"viewactivityredacted", "VIEWACTIVITYREDACTED", "NOVIEWACTIVITYREDACTED", false, "Like `viewactivity`, with the query constants redacted."},
+	{"cancelquery", "CANCELQUERY", "NOCANCELQUERY", false, "Allow the user to cancel the queries and sessions of other users."},
+	{"modifyclustersetting", "MODIFYCLUSTERSETTING", "NOMODIFYCLUSTERSETTING", false, "Allow the user to modify cluster settings."},
+	{"viewclustersetting", "VIEWCLUSTERSETTING", "NOVIEWCLUSTERSETTING", false, "Allow the user to view cluster settings."},
+}
+
+const (
+	passwordUpperChars   = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	passwordLowerChars   = "abcdefghijklmnopqrstuvwxyz"
+	passwordNumericChars = "0123456789"
+	passwordSpecialChars = "!#$%&*()-_=+[]{}<>:?"
 )
 
 func resourceUser() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		// This description is used by the documentation generator and the language server.
 		Description: "Resource used to create a new user inside Cockroachdb cluster, and to attach required roles to the user.",
 
@@ -30,6 +108,7 @@ func resourceUser() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: resourceUserImporter,
 		},
+		CustomizeDiff: resourceUserCustomizeDiff,
 
 		Schema: map[string]*schema.Schema{
 			dbUsernameAttr: {
@@ -39,18 +118,213 @@ func resourceUser() *schema.Resource {
 				ForceNew:    true,
 			},
 			dbPasswordAttr: {
-				Description: "Password of the user to create.",
+				Description:   "Password of the user to create, leave empty for users authenticating only with certificates.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				Sensitive:     true,
+				Default:       "",
+				ConflictsWith: []string{dbPasswordHashAttr, dbGeneratePasswordAttr},
+			},
+			dbPasswordHashAttr: {
+				Description:   "Pre-hashed password of the user, a `SCRAM-SHA-256$...` or `CRDB-BCRYPT$...` value, so the plaintext password never reaches the cluster.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				Sensitive:     true,
+				Default:       "",
+				ConflictsWith: []string{dbPasswordAttr, dbGeneratePasswordAttr},
+				ValidateFunc:  validatePasswordHash,
+			},
+			dbGeneratePasswordAttr: {
+				Description:   "Generate the password of the user instead of passing it in, the result is exposed in `generated_password`.",
+				Type:          schema.TypeList,
+				MaxItems:      1,
+				Optional:      true,
+				ConflictsWith: []string{dbPasswordAttr, dbPasswordHashAttr},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						passwordLengthAttr: {
+							Description:  "Length of the generated password.",
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      32,
+							ValidateFunc: validation.IntBetween(12, 128),
+						},
+						passwordUpperAttr: {
+							Description: "Include upper case letters.",
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     true,
+						},
+						passwordLowerAttr: {
+							Description: "Include lower case letters.",
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     true,
+						},
+						passwordNumericAttr: {
+							Description: "Include digits.",
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     true,
+						},
+						passwordSpecialAttr: {
+							Description: "Include special characters.",
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     true,
+						},
+						passwordOverrideSpecialAttr: {
+							Description: "Special characters to use instead of the default `" + passwordSpecialChars + "`.",
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "",
+						},
+					},
+				},
+			},
+			dbGeneratedPasswordAttr: {
+				Description: "Password generated by `generate_password`.",
 				Type:        schema.TypeString,
-				Optional:    true,
+				Computed:    true,
 				Sensitive:   true,
-				Default:     "NULL",
+			},
+			dbRotationTriggerAttr: {
+				Description: "Arbitrary value, the generated password is rotated whenever it changes.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+			},
+			dbRotateAfterAttr: {
+				Description:  "Rotate the generated password when it is older than this duration, for example `720h`.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validateDuration,
+			},
+			dbRotationGracePeriodAttr: {
+				Description: "Keep the previous generated password valid for this duration after a rotation, for example `24h`. " +
+					"CockroachDB allows a single password per user, so the previous password moves to the shadow user `previous_username` " +
+					"which is a member of the user, can't log in after the grace period and is dropped by the next apply.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "",
+				ValidateFunc: validateDuration,
+			},
+			dbLastRotatedAttr: {
+				Description: "Time the generated password was last rotated, in RFC 3339 format.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			dbPreviousUsernameAttr: {
+				Description: "Shadow user holding the previous generated password during the grace period.",
+				Type:        schema.TypeString,
+				Computed:    true,
 			},
 			dbRolesAttr: {
-				Description: "Roles to attach to the created user.",
+				Description: "Raw role options appended to `CREATE USER`, these are not read back.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+				Deprecated:  "Use the typed role options, such as `createdb` or `controljob`, instead.",
+			},
+			dbValidUntilAttr: {
+				Description:      "Date and time after which the password of the user is no longer valid, for example `2023-01-01 00:00:00+00:00`.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				Default:          "",
+				DiffSuppressFunc: suppressTimestampDiff,
+			},
+			dbConnectionLimitAttr: {
+				Description: "Maximum number of concurrent connections of the user, -1 means no limit.",
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     -1,
+			},
+			dbReassignOwnedToAttr: {
+				Description: "On destroy, reassign the objects owned by the user to this role with `REASSIGN OWNED BY` before dropping the user.",
 				Type:        schema.TypeString,
 				Optional:    true,
 				Default:     "",
 			},
+			dbDropOwnedAttr: {
+				Description: "On destroy, drop the objects owned by the user and revoke its privileges with `DROP OWNED BY` before dropping the user.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			dbRevokePrivilegesAttr: {
+				Description: "On destroy, revoke all the privileges of the user on databases, schemas, tables and types, and the default privileges defined by or granted to the user, before dropping the user.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			dbConnectionParametersAttr: {
+				Description: "Parameters of the connection strings exposed in `connection_uri` and `jdbc_url`.",
+				Type:        schema.TypeList,
+				MaxItems:    1,
+				Optional:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						connectionDatabaseAttr: {
+							Description: "Database the application connects to.",
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "defaultdb",
+						},
+						connectionHostAttr: {
+							Description: "Host the application connects to. (default is the host of the provider connection, or `<service_name>.<namespace>.svc.cluster.local` when `kube_config` is used)",
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "",
+						},
+						connectionPortAttr: {
+							Description: "Port the application connects to. (default is the port of the provider connection, or `remote_port` when `kube_config` is used)",
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "",
+						},
+						connectionSslModeAttr: {
+							Description: "SSL mode of the connection. (default is `verify-full` when certificates are configured, otherwise the SSL mode of the provider connection)",
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "",
+							ValidateFunc: validation.StringInSlice([]string{
+								"disable", "allow", "prefer", "require", "verify-ca", "verify-full",
+							}, false),
+						},
+						connectionSslRootCertAttr: {
+							Description: "Path of the CA certificate on the application host.",
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "",
+						},
+						connectionSslCertAttr: {
+							Description: "Path of the client certificate of the user on the application host.",
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "",
+						},
+						connectionSslKeyAttr: {
+							Description: "Path of the client key of the user on the application host, JDBC expects a PKCS#8 DER key.",
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "",
+						},
+					},
+				},
+			},
+			dbConnectionUriAttr: {
+				Description: "`postgresql://` connection URI of the user.",
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+			},
+			dbJdbcUrlAttr: {
+				Description: "JDBC URL of the user.",
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+			},
 			dbAdminAttr: {
 				Description: "True if the user is admin or false otherwise.",
 				Type:        schema.TypeBool,
@@ -64,6 +338,19 @@ func resourceUser() *schema.Resource {
 			},
 		},
 	}
+
+	// options left out of the configuration are read back from the cluster,
+	// including the ones set through the deprecated raw roles
+	for _, option := range roleOptions {
+		r.Schema[option.attr] = &schema.Schema{
+			Description: option.description,
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Computed:    true,
+		}
+	}
+
+	return r
 }
 
 func resourceUserCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -72,6 +359,7 @@ func resourceUserCreate(ctx context.Context, d *schema.ResourceData, meta interf
 	local_port := d.Get(argLocalPort).(string)
 	name := d.Get(dbUsernameAttr).(string)
 	password := d.Get(dbPasswordAttr).(string)
+	password_hash := d.Get(dbPasswordHashAttr).(string)
 	roles := d.Get(dbRolesAttr).(string)
 	isAdmin := d.Get(dbAdminAttr).(bool)
 	dns := strings.Replace(cockroachClient.dns, "<local_port>", local_port, 1)
@@ -90,8 +378,16 @@ func resourceUserCreate(ctx context.Context, d *schema.ResourceData, meta interf
 		return diag.Errorf("username can't be an empty string")
 	}
 
-	if password == "" {
-		return diag.Errorf("password can't be an empty string")
+	generated_password := ""
+	last_rotated := ""
+	if policy := d.Get(dbGeneratePasswordAttr).([]interface{}); len(policy) > 0 {
+		var err error
+		generated_password, err = generatePassword(policy[0].(map[string]interface{}))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		password = generated_password
+		last_rotated = time.Now().UTC().Format(time.RFC3339)
 	}
 
 	tryPortForwardIfNeeded(ctx, d, meta, stopCh, readyCh, local_port)
@@ -109,9 +405,11 @@ func resourceUserCreate(ctx context.Context, d *schema.ResourceData, meta interf
 	_, err = conn.Exec(ctx,
 		`CREATE USER `+
 			pq.QuoteIdentifier(name)+
-			` WITH PASSWORD '`+
-			password+
-			`' `+
+			` WITH `+
+			passwordClause(password, password_hash)+
+			` `+
+			userOptionsClause(d, false)+
+			` `+
 			roles,
 	)
 
@@ -133,12 +431,24 @@ func resourceUserCreate(ctx context.Context, d *schema.ResourceData, meta interf
 		}
 	}
 
+	if err := readUserOptions(ctx, conn, d, name); err != nil {
+		return diag.FromErr(err)
+	}
+
 	d.SetId(name)
 	d.Set(dbNameAttr, name)
-	d.Set(dbPasswordAttr, password)
+	d.Set(dbPasswordAttr, d.Get(dbPasswordAttr).(string))
+	d.Set(dbPasswordHashAttr, password_hash)
+	d.Set(dbGeneratedPasswordAttr, generated_password)
+	d.Set(dbLastRotatedAttr, last_rotated)
+	d.Set(dbPreviousUsernameAttr, "")
 	d.Set(dbRolesAttr, roles)
 	d.Set(dbAdminAttr, isAdmin)
 
+	if diags := setConnectionStrings(d, cockroachClient); diags.HasError() {
+		return diags
+	}
+
 	close(stopCh)
 
 	return diag.Diagnostics{}
@@ -194,11 +504,6 @@ func resourceUserRead(ctx context.Context, d *schema.ResourceData, meta interfac
 		}
 
 		if username == name {
-			// TODO: find a way to read all the roles
-			// if err := d.Set(dbRolesAttr, options); err != nil {
-			// 	return diag.FromErr(err)
-			// }
-
 			if err := d.Set(dbAdminAttr, contains(member_of, "admin")); err != nil {
 				return diag.FromErr(err)
 			}
@@ -212,10 +517,25 @@ func resourceUserRead(ctx context.Context, d *schema.ResourceData, meta interfac
 		return diag.FromErr(err)
 	}
 
+	rows.Close()
+
 	if found == false {
-		if err := d.Set(dbNameAttr, ""); err != nil {
-			return diag.FromErr(err)
-		}
+		logInfo("user %s not found, removing it from state", name)
+		d.SetId("")
+		close(stopCh)
+		return nil
+	}
+
+	if err := readUserOptions(ctx, conn, d, name); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set(dbUsernameAttr, name); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if diags := setConnectionStrings(d, cockroachClient); diags.HasError() {
+		return diags
 	}
 
 	close(stopCh)
@@ -252,37 +572,90 @@ func resourceUserUpdate(ctx context.Context, d *schema.ResourceData, meta interf
 	if err := conn.Ping(ctx); err != nil {
 		return diag.FromErr(err)
 	}
-	if d.HasChange(dbAdminAttr) || d.HasChange(dbRolesAttr) || d.HasChange(dbPasswordAttr) {
-		_, npass := d.GetChange(dbPasswordAttr)
-		oadmin, nadmin := d.GetChange(dbAdminAttr)
-		_, nroles := d.GetChange(dbRolesAttr)
-		oadmin = oadmin.(bool)
-		nadmin = nadmin.(bool)
 
-		name := d.Id()
-		password := npass.(string)
-		roles := nroles.(string)
+	name := d.Id()
+
+	generate := len(d.Get(dbGeneratePasswordAttr).([]interface{})) > 0
+
+	// the password is changed on its own, so the role options are not
+	// re-issued on every password change
+	if !generate && (d.HasChange(dbPasswordAttr) || d.HasChange(dbPasswordHashAttr) || d.HasChange(dbGeneratePasswordAttr)) {
+		password := d.Get(dbPasswordAttr).(string)
+		password_hash := d.Get(dbPasswordHashAttr).(string)
+
+		_, err := conn.Exec(ctx,
+			`ALTER USER `+
+				pq.QuoteIdentifier(name)+
+				` WITH `+
+				passwordClause(password, password_hash),
+		)
+
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		d.Set(dbPasswordAttr, password)
+		d.Set(dbPasswordHashAttr, password_hash)
+		d.Set(dbGeneratedPasswordAttr, "")
+		d.Set(dbLastRotatedAttr, "")
+	}
+
+	// the plan marks the generated password as unknown when it has to rotate,
+	// and the shadow user as unknown once its grace period is over
+	if generate && (d.HasChange(dbGeneratedPasswordAttr) || d.HasChange(dbGeneratePasswordAttr) || d.HasChange(dbRotationTriggerAttr)) {
+		if diags := rotateGeneratedPassword(ctx, d, conn); diags.HasError() {
+			return diags
+		}
+	} else if d.HasChange(dbPreviousUsernameAttr) {
+		previous_username, _ := d.GetChange(dbPreviousUsernameAttr)
+
+		if previous_username.(string) != "" {
+			_, err = conn.Exec(ctx, `DROP USER IF EXISTS `+pq.QuoteIdentifier(previous_username.(string)))
+			if err != nil {
+				return diag.FromErr(err)
+			}
+		}
+
+		d.Set(dbPreviousUsernameAttr, "")
+	}
+
+	if d.HasChange(dbRolesAttr) {
+		roles := d.Get(dbRolesAttr).(string)
+
+		if roles != "" {
+			_, err := conn.Exec(ctx,
+				`ALTER USER `+
+					pq.QuoteIdentifier(name)+
+					` WITH `+
+					roles,
+			)
 
-		if password == "" {
-			return diag.Errorf("User password cannot be empty")
+			if err != nil {
+				return diag.FromErr(err)
+			}
 		}
 
-		// ALTER user
+		d.Set(dbRolesAttr, roles)
+	}
+
+	if options := userOptionsClause(d, true); options != "" {
 		_, err := conn.Exec(ctx,
 			`ALTER USER `+
 				pq.QuoteIdentifier(name)+
-				` WITH PASSWORD '`+
-				password+
-				`' `+
-				roles,
+				` WITH `+
+				options,
 		)
 
 		if err != nil {
 			return diag.FromErr(err)
 		}
+	}
+
+	if d.HasChange(dbAdminAttr) {
+		oadmin, nadmin := d.GetChange(dbAdminAttr)
 
 		// disable or grant admin
-		if oadmin == true && nadmin == false {
+		if oadmin.(bool) == true && nadmin.(bool) == false {
 			// revoke admin
 			_, err := conn.Exec(ctx,
 				`REVOKE admin from `+
@@ -294,7 +667,7 @@ func resourceUserUpdate(ctx context.Context, d *schema.ResourceData, meta interf
 			}
 		}
 
-		if oadmin == false && nadmin == true {
+		if oadmin.(bool) == false && nadmin.(bool) == true {
 			// grant admin priviledged
 			_, err := conn.Exec(ctx,
 				`GRANT admin to `+
@@ -308,8 +681,10 @@ func resourceUserUpdate(ctx context.Context, d *schema.ResourceData, meta interf
 		}
 
 		d.Set(dbAdminAttr, nadmin)
-		d.Set(dbRolesAttr, nroles)
-		d.Set(dbPasswordAttr, npass)
+	}
+
+	if diags := setConnectionStrings(d, cockroachClient); diags.HasError() {
+		return diags
 	}
 
 	close(stopCh)
@@ -350,9 +725,85 @@ func resourceUserDelete(ctx context.Context, d *schema.ResourceData, meta interf
 		return diag.Errorf("User name can't be an empty string")
 	}
 
+	if previous_username := d.Get(dbPreviousUsernameAttr).(string); previous_username != "" {
+		_, err = conn.Exec(ctx, `DROP USER IF EXISTS `+pq.QuoteIdentifier(previous_username))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	reassign_owned_to := d.Get(dbReassignOwnedToAttr).(string)
+	drop_owned := d.Get(dbDropOwnedAttr).(bool)
+	revoke_privileges := d.Get(dbRevokePrivilegesAttr).(bool)
+
+	if reassign_owned_to != "" || drop_owned || revoke_privileges {
+		databases, err := readDatabaseNames(ctx, conn)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		var current_database string
+		if err := conn.QueryRow(ctx, `SELECT current_database()`).Scan(&current_database); err != nil {
+			return diag.FromErr(err)
+		}
+
+		// REASSIGN OWNED and DROP OWNED only apply to the current database
+		for _, database := range databases {
+			if _, err := conn.Exec(ctx, `SET database = `+pq.QuoteIdentifier(database)); err != nil {
+				return diag.FromErr(err)
+			}
+
+			if reassign_owned_to != "" {
+				_, err = conn.Exec(ctx,
+					`REASSIGN OWNED BY `+
+						pq.QuoteIdentifier(username)+
+						` TO `+
+						pq.QuoteIdentifier(reassign_owned_to),
+				)
+				if err != nil {
+					return diag.FromErr(err)
+				}
+			}
+
+			if drop_owned {
+				_, err = conn.Exec(ctx, `DROP OWNED BY `+pq.QuoteIdentifier(username))
+				if err != nil {
+					return diag.FromErr(err)
+				}
+			}
+
+			if revoke_privileges {
+				if err := revokeAllPrivileges(ctx, conn, database, username); err != nil {
+					return diag.FromErr(err)
+				}
+			}
+		}
+
+		if _, err := conn.Exec(ctx, `SET database = `+pq.QuoteIdentifier(current_database)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
 	_, err = conn.Exec(ctx, `DROP USER `+pq.QuoteIdentifier(username))
 	if err != nil {
-		return diag.FromErr(err)
+		if reassign_owned_to != "" || drop_owned || revoke_privileges {
+			return diag.FromErr(err)
+		}
+
+		dependencies, derr := readUserDependencies(ctx, conn, username)
+		if derr != nil || len(dependencies) == 0 {
+			return diag.FromErr(err)
+		}
+
+		return diag.Diagnostics{
+			{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("User %s owns objects or has privileges", username),
+				Detail: fmt.Sprintf("%s\n\nThe user can't be dropped because of:\n  - %s\n\n"+
+					"Set %s, %s or %s to clean these up on destroy.",
+					err, strings.Join(dependencies, "\n  - "), dbReassignOwnedToAttr, dbDropOwnedAttr, dbRevokePrivilegesAttr),
+			},
+		}
 	}
 
 	d.SetId("")
@@ -365,6 +816,10 @@ func resourceUserDelete(ctx context.Context, d *schema.ResourceData, meta interf
 		return diag.FromErr(err)
 	}
 
+	if err := d.Set(dbPasswordHashAttr, ""); err != nil {
+		return diag.FromErr(err)
+	}
+
 	if err := d.Set(dbRolesAttr, ""); err != nil {
 		return diag.FromErr(err)
 	}
@@ -377,7 +832,852 @@ func resourceUserDelete(ctx context.Context, d *schema.ResourceData, meta interf
 	return diag.Diagnostics{}
 }
 
+func resourceUserCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	// a new resource gets its password and connection strings on create
+	if d.Id() == "" {
+		return nil
+	}
+
+	rotate := false
+
+	if len(d.Get(dbGeneratePasswordAttr).([]interface{})) > 0 {
+		rotate = d.HasChange(dbGeneratePasswordAttr) || d.HasChange(dbRotationTriggerAttr)
+
+		if rotate_after := d.Get(dbRotateAfterAttr).(string); rotate_after != "" {
+			due, err := rotationDue(d.Get(dbLastRotatedAttr).(string), rotate_after, time.Now())
+			if err != nil {
+				return err
+			}
+			rotate = rotate || due
+		}
+	}
+
+	if rotate {
+		if err := d.SetNewComputed(dbGeneratedPasswordAttr); err != nil {
+			return err
+		}
+
+		if err := d.SetNewComputed(dbLastRotatedAttr); err != nil {
+			return err
+		}
+
+		if err := d.SetNewComputed(dbPreviousUsernameAttr); err != nil {
+			return err
+		}
+	}
+
+	// the shadow user only expires with VALID UNTIL, apply drops it once the
+	// grace period is over
+	if previous_username := d.Get(dbPreviousUsernameAttr).(string); !rotate && previous_username != "" {
+		expired, err := shadowUserExpired(d.Get(dbLastRotatedAttr).(string), d.Get(dbRotationGracePeriodAttr).(string), time.Now())
+		if err != nil {
+			return err
+		}
+
+		if expired {
+			if err := d.SetNewComputed(dbPreviousUsernameAttr); err != nil {
+				return err
+			}
+		}
+	}
+
+	// the connection strings embed the password
+	if rotate || d.HasChange(dbPasswordAttr) || d.HasChange(dbPasswordHashAttr) || d.HasChange(dbGeneratePasswordAttr) || d.HasChange(dbConnectionParametersAttr) {
+		if err := d.SetNewComputed(dbConnectionUriAttr); err != nil {
+			return err
+		}
+
+		return d.SetNewComputed(dbJdbcUrlAttr)
+	}
+
+	return nil
+}
+
+// rotateGeneratedPassword sets a new generated password, the previous one
+// moves to a shadow user when a grace period is configured. The shadow user
+// is dropped again when the password can't be changed, so it never exists
+// without being recorded in the state.
+func rotateGeneratedPassword(ctx context.Context, d *schema.ResourceData, conn *pgx.Conn) diag.Diagnostics {
+	name := d.Id()
+	policy := d.Get(dbGeneratePasswordAttr).([]interface{})[0].(map[string]interface{})
+	old_password, _ := d.GetChange(dbGeneratedPasswordAttr)
+	old_previous_username, _ := d.GetChange(dbPreviousUsernameAttr)
+	grace_period := d.Get(dbRotationGracePeriodAttr).(string)
+	previous_username := name + "_previous"
+
+	password, err := generatePassword(policy)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	now := time.Now().UTC()
+
+	// the shadow user of an older rotation is replaced, only the user
+	// recorded in the state is dropped
+	if old_previous_username.(string) != "" {
+		_, err = conn.Exec(ctx, `DROP USER IF EXISTS `+pq.QuoteIdentifier(old_previous_username.(string)))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if grace_period != "" && old_password.(string) != "" {
+		grace, err := time.ParseDuration(grace_period)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		var exists bool
+		err = conn.QueryRow(ctx, `SELECT count(*) > 0 FROM system.users WHERE username = $1`, previous_username).Scan(&exists)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		if exists {
+			return diag.Errorf("role %s already exists outside of Terraform, it can't be used as the shadow user of %s", previous_username, name)
+		}
+
+		_, err = conn.Exec(ctx,
+			`CREATE USER `+
+				pq.QuoteIdentifier(previous_username)+
+				` WITH `+
+				passwordClause(old_password.(string), "")+
+				` VALID UNTIL `+
+				pq.QuoteLiteral(now.Add(grace).Format("2006-01-02 15:04:05-07:00")),
+		)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		// membership gives the shadow user the privileges of the user
+		_, err = conn.Exec(ctx,
+			`GRANT `+
+				pq.QuoteIdentifier(name)+
+				` TO `+
+				pq.QuoteIdentifier(previous_username),
+		)
+		if err != nil {
+			return dropShadowUser(ctx, conn, previous_username, err)
+		}
+	} else {
+		previous_username = ""
+	}
+
+	_, err = conn.Exec(ctx,
+		`ALTER USER `+
+			pq.QuoteIdentifier(name)+
+			` WITH `+
+			passwordClause(password, ""),
+	)
+	if err != nil {
+		if previous_username != "" {
+			return dropShadowUser(ctx, conn, previous_username, err)
+		}
+		return diag.FromErr(err)
+	}
+
+	d.Set(dbGeneratedPasswordAttr, password)
+	d.Set(dbLastRotatedAttr, now.Format(time.RFC3339))
+	d.Set(dbPreviousUsernameAttr, previous_username)
+
+	return diag.Diagnostics{}
+}
+
+// dropShadowUser drops a shadow user created by a rotation which failed
+// afterwards and reports the failure.
+func dropShadowUser(ctx context.Context, conn *pgx.Conn, previous_username string, err error) diag.Diagnostics {
+	if _, drop_err := conn.Exec(ctx, `DROP USER IF EXISTS `+pq.QuoteIdentifier(previous_username)); drop_err != nil {
+		return diag.Errorf("%s, the shadow user %s could not be dropped: %s", err, previous_username, drop_err)
+	}
+
+	return diag.FromErr(err)
+}
+
+// rotationDue reports whether a password rotated at last_rotated is older
+// than rotate_after.
+func rotationDue(last_rotated string, rotate_after string, now time.Time) (bool, error) {
+	if last_rotated == "" {
+		return false, nil
+	}
+
+	rotated, err := time.Parse(time.RFC3339, last_rotated)
+	if err != nil {
+		return false, err
+	}
+
+	after, err := time.ParseDuration(rotate_after)
+	if err != nil {
+		return false, err
+	}
+
+	return !now.Before(rotated.Add(after)), nil
+}
+
+// shadowUserExpired reports whether the grace period of the shadow user
+// created at last_rotated is over, without a grace period it is always over.
+func shadowUserExpired(last_rotated string, grace_period string, now time.Time) (bool, error) {
+	if grace_period == "" || last_rotated == "" {
+		return true, nil
+	}
+
+	return rotationDue(last_rotated, grace_period, now)
+}
+
+// generatePassword generates a password following the generate_password
+// policy, with at least one character of every enabled class.
+func generatePassword(policy map[string]interface{}) (string, error) {
+	special := passwordSpecialChars
+	if override := policy[passwordOverrideSpecialAttr].(string); override != "" {
+		special = override
+	}
+
+	classes := []string{}
+	if policy[passwordUpperAttr].(bool) {
+		classes = append(classes, passwordUpperChars)
+	}
+	if policy[passwordLowerAttr].(bool) {
+		classes = append(classes, passwordLowerChars)
+	}
+	if policy[passwordNumericAttr].(bool) {
+		classes = append(classes, passwordNumericChars)
+	}
+	if policy[passwordSpecialAttr].(bool) {
+		classes = append(classes, special)
+	}
+
+	length := policy[passwordLengthAttr].(int)
+	if len(classes) == 0 {
+		return "", fmt.Errorf("generate_password must enable at least one character class")
+	}
+	if length < len(classes) {
+		return "", fmt.Errorf("generate_password length must be at least %d", len(classes))
+	}
+
+	password := make([]byte, 0, length)
+	for _, class := range classes {
+		c, err := randomChar(class)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+
+	all := strings.Join(classes, "")
+	for len(password) < length {
+		c, err := randomChar(all)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+
+	// shuffle, so the mandatory characters are not always first
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j.Int64()] = password[j.Int64()], password[i]
+	}
+
+	return string(password), nil
+}
+
+func randomChar(chars string) (byte, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
+	if err != nil {
+		return 0, err
+	}
+
+	return chars[i.Int64()], nil
+}
+
+func validateDuration(v interface{}, k string) ([]string, []error) {
+	value := v.(string)
+	if value == "" {
+		return nil, nil
+	}
+
+	if _, err := time.ParseDuration(value); err != nil {
+		return nil, []error{fmt.Errorf("%s must be a duration like 24h: %s", k, err)}
+	}
+
+	return nil, nil
+}
+
+// revokeAllPrivileges revokes the privileges of the user on the database and
+// on every schema, table and type inside it, as well as the default
+// privileges defined by or granted to the user.
+func revokeAllPrivileges(ctx context.Context, conn *pgx.Conn, database string, username string) error {
+	schemas, err := queryStrings(ctx, conn,
+		`SELECT schema_name FROM `+
+			pq.QuoteIdentifier(database)+
+			`.information_schema.schemata WHERE schema_name != ALL ($1) ORDER BY schema_name`,
+		virtualSchemas,
+	)
+	if err != nil {
+		return err
+	}
+
+	types, err := readUserDefinedTypes(ctx, conn, database)
+	if err != nil {
+		return err
+	}
+
+	default_privileges, err := readDefaultPrivileges(ctx, conn, database, username)
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range revokePrivilegesStatements(database, username, schemas, types, default_privileges) {
+		if _, err := conn.Exec(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// revokePrivilegesStatements builds the statements revoking every privilege
+// of the user in a database. ALTER DEFAULT PRIVILEGES applies to the current
+// database, which must be the database of the privileges.
+func revokePrivilegesStatements(database string, username string, schemas []string, types []qualifiedName, default_privileges []defaultPrivilege) []string {
+	user := pq.QuoteIdentifier(username)
+
+	statements := []string{`REVOKE ALL ON DATABASE ` + pq.QuoteIdentifier(database) + ` FROM ` + user}
+
+	for _, schema_name := range schemas {
+		qualified := pq.QuoteIdentifier(database) + `.` + pq.QuoteIdentifier(schema_name)
+
+		statements = append(statements,
+			`REVOKE ALL ON SCHEMA `+qualified+` FROM `+user,
+			`REVOKE ALL ON TABLE `+qualified+`.* FROM `+user,
+		)
+	}
+
+	for _, type_name := range types {
+		statements = append(statements,
+			`REVOKE ALL ON TYPE `+
+				pq.QuoteIdentifier(database)+`.`+pq.QuoteIdentifier(type_name.schema)+`.`+pq.QuoteIdentifier(type_name.name)+
+				` FROM `+user,
+		)
+	}
+
+	for _, privilege := range default_privileges {
+		statements = append(statements, privilege.revokeStatement())
+	}
+
+	return statements
+}
+
+// qualifiedName is the name of an object inside a schema.
+type qualifiedName struct {
+	schema string
+	name   string
+}
+
+// defaultPrivilege is a default privilege of crdb_internal.default_privileges,
+// role is empty when the privilege applies to all roles.
+type defaultPrivilege struct {
+	role        string
+	schema      string
+	object_type string
+	grantee     string
+}
+
+// revokeStatement builds the ALTER DEFAULT PRIVILEGES statement removing the
+// default privilege.
+func (p defaultPrivilege) revokeStatement() string {
+	statement := `ALTER DEFAULT PRIVILEGES FOR ALL ROLES`
+	if p.role != "" {
+		statement = `ALTER DEFAULT PRIVILEGES FOR ROLE ` + pq.QuoteIdentifier(p.role)
+	}
+
+	if p.schema != "" {
+		statement += ` IN SCHEMA ` + pq.QuoteIdentifier(p.schema)
+	}
+
+	return statement + ` REVOKE ALL ON ` + strings.ToUpper(p.object_type) + ` FROM ` + pq.QuoteIdentifier(p.grantee)
+}
+
+// description describes the default privilege as a dependency of user.
+func (p defaultPrivilege) description(database string, username string) string {
+	scope := "database " + database
+	if p.schema != "" {
+		scope = "schema " + database + "." + p.schema
+	}
+
+	if p.role == username {
+		return fmt.Sprintf("default privileges on %s in %s granted to %s", p.object_type, scope, p.grantee)
+	}
+
+	role := p.role
+	if role == "" {
+		role = "all roles"
+	}
+
+	return fmt.Sprintf("default privileges on %s in %s granted by %s", p.object_type, scope, role)
+}
+
+// readUserDefinedTypes lists the user defined types of a database.
+func readUserDefinedTypes(ctx context.Context, conn *pgx.Conn, database string) ([]qualifiedName, error) {
+	rows, err := conn.Query(ctx,
+		`SELECT schema_name, descriptor_name FROM `+
+			pq.QuoteIdentifier(database)+
+			`.crdb_internal.create_type_statements WHERE database_name = $1 ORDER BY schema_name, descriptor_name`,
+		database,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types := []qualifiedName{}
+	for rows.Next() {
+		var type_name qualifiedName
+		if err := rows.Scan(&type_name.schema, &type_name.name); err != nil {
+			return nil, err
+		}
+		types = append(types, type_name)
+	}
+
+	return types, rows.Err()
+}
+
+// readDefaultPrivileges lists the default privileges of a database defined by
+// or granted to the user, default privileges exist since v21.2.
+func readDefaultPrivileges(ctx context.Context, conn *pgx.Conn, database string, username string) ([]defaultPrivilege, error) {
+	supported, err := clusterVersionAtLeast(ctx, conn, "21.2")
+	if err != nil || !supported {
+		return nil, err
+	}
+
+	rows, err := conn.Query(ctx,
+		`SELECT DISTINCT COALESCE(role, ''), COALESCE(schema_name, ''), object_type, grantee FROM `+
+			pq.QuoteIdentifier(database)+
+			`.crdb_internal.default_privileges
+		WHERE database_name = $1 AND (role = $2 OR grantee = $2)
+		ORDER BY 1, 2, 3, 4`,
+		database, username,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	default_privileges := []defaultPrivilege{}
+	for rows.Next() {
+		var privilege defaultPrivilege
+		if err := rows.Scan(&privilege.role, &privilege.schema, &privilege.object_type, &privilege.grantee); err != nil {
+			return nil, err
+		}
+		default_privileges = append(default_privileges, privilege)
+	}
+
+	return default_privileges, rows.Err()
+}
+
+// readUserDependencies lists the objects which prevent dropping the user.
+func readUserDependencies(ctx context.Context, conn *pgx.Conn, username string) ([]string, error) {
+	databases, err := readDatabaseNames(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	dependencies := []string{}
+
+	rows, err := conn.Query(ctx, `SELECT name FROM crdb_internal.databases WHERE owner = $1 ORDER BY name`, username)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		dependencies = append(dependencies, "owner of database "+name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, database := range databases {
+		rows, err := conn.Query(ctx,
+			`SELECT 'owner of ' || CASE c.relkind WHEN 'S' THEN 'sequence ' WHEN 'v' THEN 'view ' ELSE 'table ' END || $2::STRING || '.' || n.nspname || '.' || c.relname
+			FROM `+pq.QuoteIdentifier(database)+`.pg_catalog.pg_class AS c
+			JOIN `+pq.QuoteIdentifier(database)+`.pg_catalog.pg_namespace AS n ON n.oid = c.relnamespace
+			JOIN `+pq.QuoteIdentifier(database)+`.pg_catalog.pg_roles AS r ON r.oid = c.relowner
+			WHERE r.rolname = $1 AND c.relkind IN ('r', 'v', 'S')
+			UNION ALL
+			SELECT 'owner of schema ' || $2::STRING || '.' || n.nspname
+			FROM `+pq.QuoteIdentifier(database)+`.pg_catalog.pg_namespace AS n
+			JOIN `+pq.QuoteIdentifier(database)+`.pg_catalog.pg_roles AS r ON r.oid = n.nspowner
+			WHERE r.rolname = $1
+			UNION ALL
+			SELECT DISTINCT 'privileges on table ' || table_catalog || '.' || table_schema || '.' || table_name
+			FROM `+pq.QuoteIdentifier(database)+`.information_schema.table_privileges
+			WHERE grantee = $1
+			UNION ALL
+			SELECT DISTINCT 'privileges on schema ' || catalog_name || '.' || schema_name
+			FROM `+pq.QuoteIdentifier(database)+`.information_schema.schema_privileges
+			WHERE grantee = $1`,
+			username, database,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var dependency string
+			if err := rows.Scan(&dependency); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			dependencies = append(dependencies, dependency)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		database_privileges, err := queryStrings(ctx, conn,
+			`SELECT DISTINCT 'privileges on database ' || database_name FROM [SHOW GRANTS ON DATABASE `+
+				pq.QuoteIdentifier(database)+
+				`] WHERE grantee = $1`,
+			username,
+		)
+		if err != nil {
+			return nil, err
+		}
+		dependencies = append(dependencies, database_privileges...)
+
+		default_privileges, err := readDefaultPrivileges(ctx, conn, database, username)
+		if err != nil {
+			return nil, err
+		}
+		for _, privilege := range default_privileges {
+			dependencies = append(dependencies, privilege.description(database, username))
+		}
+	}
+
+	return dependencies, nil
+}
+
+// userOptionsClause builds the typed role options of CREATE/ALTER USER, only
+// the changed options when changed_only is set. CREATE USER only sends the
+// configured options which differ from the default, older clusters do not
+// know every option and non-admin roles can't set all of them.
+func userOptionsClause(d *schema.ResourceData, changed_only bool) string {
+	clauses := []string{}
+
+	// options still passed through the deprecated raw roles would conflict
+	raw_options := strings.Fields(strings.ToUpper(d.Get(dbRolesAttr).(string)))
+
+	for _, option := range roleOptions {
+		if changed_only && !d.HasChange(option.attr) {
+			continue
+		}
+
+		if !changed_only {
+			//nolint:staticcheck // GetOk can't tell false from unset
+			if value, ok := d.GetOkExists(option.attr); !ok || value.(bool) == option.defaultValue {
+				continue
+			}
+		}
+
+		if contains(raw_options, option.keyword) || contains(raw_options, option.negation) {
+			continue
+		}
+
+		if d.Get(option.attr).(bool) {
+			clauses = append(clauses, option.keyword)
+		} else {
+			clauses = append(clauses, option.negation)
+		}
+	}
+
+	if !changed_only || d.HasChange(dbValidUntilAttr) {
+		if valid_until := d.Get(dbValidUntilAttr).(string); valid_until != "" {
+			clauses = append(clauses, "VALID UNTIL "+pq.QuoteLiteral(valid_until))
+		} else if changed_only {
+			clauses = append(clauses, "VALID UNTIL NULL")
+		}
+	}
+
+	// the connection limit is only sent when set, older clusters do not
+	// know the option
+	if connection_limit := d.Get(dbConnectionLimitAttr).(int); (changed_only && d.HasChange(dbConnectionLimitAttr)) || (!changed_only && connection_limit != -1) {
+		clauses = append(clauses, fmt.Sprintf("CONNECTION LIMIT %d", connection_limit))
+	}
+
+	return strings.Join(clauses, " ")
+}
+
+// readUserOptions sets the typed role options, valid_until and
+// connection_limit from the cluster.
+func readUserOptions(ctx context.Context, conn *pgx.Conn, d *schema.ResourceData, name string) error {
+	options, err := readRoleOptions(ctx, conn, name)
+	if err != nil {
+		return err
+	}
+
+	for _, option := range roleOptions {
+		if err := d.Set(option.attr, roleOptionEnabled(options, option)); err != nil {
+			return err
+		}
+	}
+
+	if err := d.Set(dbValidUntilAttr, options["VALID UNTIL"]); err != nil {
+		return err
+	}
+
+	var connection_limit int
+	err = conn.QueryRow(ctx, `SELECT rolconnlimit FROM pg_catalog.pg_roles WHERE rolname = $1`, name).Scan(&connection_limit)
+	if err != nil {
+		return err
+	}
+
+	return d.Set(dbConnectionLimitAttr, connection_limit)
+}
+
+// readRoleOptions reads the role options which differ from the defaults,
+// keyed by option with the value of VALID UNTIL.
+func readRoleOptions(ctx context.Context, conn *pgx.Conn, name string) (map[string]string, error) {
+	rows, err := conn.Query(ctx, `SELECT option, value FROM system.role_options WHERE username = $1`, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	options := map[string]string{}
+	for rows.Next() {
+		var (
+			option string
+			value  sql.NullString
+		)
+		if err := rows.Scan(&option, &value); err != nil {
+			return nil, err
+		}
+		options[option] = value.String
+	}
+
+	return options, rows.Err()
+}
+
+func roleOptionEnabled(options map[string]string, option roleOption) bool {
+	if _, ok := options[option.keyword]; ok {
+		return true
+	}
+
+	if _, ok := options[option.negation]; ok {
+		return false
+	}
+
+	return option.defaultValue
+}
+
+// suppressTimestampDiff ignores the formatting differences of timestamps, for
+// example the value read back from system.role_options.
+func suppressTimestampDiff(k, old, new string, d *schema.ResourceData) bool {
+	if old == new {
+		return true
+	}
+
+	o, err := parseTimestamp(old)
+	if err != nil {
+		return false
+	}
+
+	n, err := parseTimestamp(new)
+	if err != nil {
+		return false
+	}
+
+	return o.Equal(n)
+}
+
+func parseTimestamp(value string) (time.Time, error) {
+	layouts := []string{
+		time.RFC3339Nano,
+		"2006-01-02 15:04:05.999999999-07:00",
+		"2006-01-02 15:04:05.999999999-07",
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02",
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unknown timestamp format: %s", value)
+}
+
+// passwordClause builds the PASSWORD option of CREATE/ALTER USER, the value is
+// escaped as a string literal and an empty password disables password login.
+func passwordClause(password string, password_hash string) string {
+	if password_hash != "" {
+		return "PASSWORD " + pq.QuoteLiteral(password_hash)
+	}
+
+	if password == "" {
+		return "PASSWORD NULL"
+	}
+
+	return "PASSWORD " + pq.QuoteLiteral(password)
+}
+
+func validatePasswordHash(v interface{}, k string) ([]string, []error) {
+	value := v.(string)
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, prefix := range passwordHashPrefixes {
+		if strings.HasPrefix(value, prefix) {
+			return nil, nil
+		}
+	}
+
+	return nil, []error{fmt.Errorf("%s must start with one of %s", k, strings.Join(passwordHashPrefixes, ", "))}
+}
+
+// setConnectionStrings sets the connection URI and the JDBC URL of the user
+// from the connection block, falling back to the provider connection.
+func setConnectionStrings(d *schema.ResourceData, cockroachClient *cockroachClient) diag.Diagnostics {
+	connection := map[string]interface{}{
+		connectionDatabaseAttr:    "defaultdb",
+		connectionHostAttr:        "",
+		connectionPortAttr:        "",
+		connectionSslModeAttr:     "",
+		connectionSslRootCertAttr: "",
+		connectionSslCertAttr:     "",
+		connectionSslKeyAttr:      "",
+	}
+	if c := d.Get(dbConnectionParametersAttr).([]interface{}); len(c) > 0 && c[0] != nil {
+		connection = c[0].(map[string]interface{})
+	}
+
+	host, port, sslmode, err := connectionDefaults(cockroachClient)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if h := connection[connectionHostAttr].(string); h != "" {
+		host = h
+	}
+
+	if p := connection[connectionPortAttr].(string); p != "" {
+		port = p
+	}
+
+	password := d.Get(dbPasswordAttr).(string)
+	if len(d.Get(dbGeneratePasswordAttr).([]interface{})) > 0 {
+		password = d.Get(dbGeneratedPasswordAttr).(string)
+	}
+
+	params := url.Values{}
+	for _, attr := range []string{connectionSslRootCertAttr, connectionSslCertAttr, connectionSslKeyAttr} {
+		if v := connection[attr].(string); v != "" {
+			params.Set(attr, v)
+			sslmode = "verify-full"
+		}
+	}
+
+	if s := connection[connectionSslModeAttr].(string); s != "" {
+		sslmode = s
+	}
+
+	if sslmode != "" {
+		params.Set(connectionSslModeAttr, sslmode)
+	}
+
+	uri, jdbc := connectionStrings(d.Get(dbUsernameAttr).(string), password, host, port, connection[connectionDatabaseAttr].(string), params)
+
+	if err := d.Set(dbConnectionUriAttr, uri); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set(dbJdbcUrlAttr, jdbc); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+// connectionDefaults returns the host, port and SSL mode applications use to
+// reach the cluster of the provider. Inside Kubernetes this is the service of
+// the cluster, the provider itself connects through a port-forward.
+func connectionDefaults(cockroachClient *cockroachClient) (string, string, string, error) {
+	if cockroachClient.kubeConn.serviceName != "" {
+		return cockroachClient.kubeConn.serviceName + "." + cockroachClient.kubeConn.nameSpace + ".svc.cluster.local",
+			cockroachClient.kubeConn.remotePort,
+			"",
+			nil
+	}
+
+	if u, err := url.Parse(cockroachClient.dns); err == nil && (u.Scheme == "postgresql" || u.Scheme == "postgres") {
+		port := u.Port()
+		if port == "" {
+			port = "26257"
+		}
+
+		return u.Hostname(), port, u.Query().Get("sslmode"), nil
+	}
+
+	// key=value connection string
+	config, err := pgx.ParseConfig(cockroachClient.dns)
+	if err != nil {
+		return "", "", "", fmt.Errorf("cannot read the host and port of the provider connection: %s", err)
+	}
+
+	return config.Host, strconv.Itoa(int(config.Port)), "", nil
+}
+
+// connectionStrings builds the postgresql:// URI and the JDBC URL of a user,
+// the password is left out when empty.
+func connectionStrings(username string, password string, host string, port string, database string, params url.Values) (string, string) {
+	uri := url.URL{
+		Scheme:   "postgresql",
+		User:     url.User(username),
+		Host:     net.JoinHostPort(host, port),
+		Path:     "/" + database,
+		RawQuery: params.Encode(),
+	}
+	if password != "" {
+		uri.User = url.UserPassword(username, password)
+	}
+
+	jdbc_params := url.Values{}
+	for k, v := range params {
+		jdbc_params[k] = v
+	}
+	jdbc_params.Set("user", username)
+	if password != "" {
+		jdbc_params.Set("password", password)
+	}
+
+	jdbc := url.URL{
+		Scheme:   "postgresql",
+		Host:     net.JoinHostPort(host, port),
+		Path:     "/" + database,
+		RawQuery: jdbc_params.Encode(),
+	}
+
+	return uri.String(), "jdbc:" + jdbc.String()
+}
+
 func resourceUserImporter(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	// id is the name of the user, the port is required but can't be part of
+	// the import
+	if d.Get(argLocalPort).(string) == "" {
+		if err := d.Set(argLocalPort, "26257"); err != nil {
+			return nil, err
+		}
+	}
+
 	err := resourceUserRead(ctx, d, meta)
 	if err != nil {
 		return nil, fmt.Errorf("Unable to import resource")
@@ -0,0 +1,351 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	secretNameAttr          = "name"
+	secretNamespaceAttr     = "namespace"
+	secretLabelsAttr        = "labels"
+	secretAnnotationsAttr   = "annotations"
+	secretUsernameAttr      = "username"
+	secretPasswordAttr      = "password"
+	secretConnectionUriAttr = "connection_uri"
+	secretCertPemAttr       = "cert_pem"
+	secretPrivateKeyPemAttr = "private_key_pem"
+	secretCaCertPemAttr     = "ca_cert_pem"
+)
+
+// keys of the credentials inside the Kubernetes Secret, the certificate keys
+// follow the kubernetes.io/tls naming
+var userSecretKeys = map[string]string{
+	secretUsernameAttr:      "username",
+	secretPasswordAttr:      "password",
+	secretConnectionUriAttr: "uri",
+	secretCertPemAttr:       "tls.crt",
+	secretPrivateKeyPemAttr: "tls.key",
+	secretCaCertPemAttr:     "ca.crt",
+}
+
+func resourceUserSecret() *schema.Resource {
+	return &schema.Resource{
+		// This description is used by the documentation generator and the language server.
+		Description: "Resource used to publish the credentials of a user to a Kubernetes Secret, using the `kube_config` of the provider. " +
+			"The Secret holds the keys `username`, `password`, `uri`, `tls.crt`, `tls.key` and `ca.crt`, empty credentials are left out.",
+
+		CreateContext: resourceUserSecretCreate,
+		ReadContext:   resourceUserSecretRead,
+		UpdateContext: resourceUserSecretUpdate,
+		DeleteContext: resourceUserSecretDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceUserSecretImporter,
+		},
+
+		Schema: map[string]*schema.Schema{
+			secretNameAttr: {
+				Description: "Name of the Secret.",
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+			},
+			secretNamespaceAttr: {
+				Description: "Namespace of the Secret. (default is the namespace of the provider `kube_config`)",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+			},
+			secretLabelsAttr: {
+				Description: "Labels of the Secret, labels added by others are left alone.",
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			secretAnnotationsAttr: {
+				Description: "Annotations of the Secret, annotations added by others, such as `kubectl.kubernetes.io/*`, are left alone.",
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			secretUsernameAttr: {
+				Description: "Name of the user, usually `cockroach_user.<name>.username`.",
+				Type:        schema.TypeString,
+				Required:    true,
+			},
+			secretPasswordAttr: {
+				Description: "Password of the user, usually `cockroach_user.<name>.generated_password`.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				Default:     "",
+			},
+			secretConnectionUriAttr: {
+				Description: "Connection URI of the user, usually `cockroach_user.<name>.connection_uri`.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				Default:     "",
+			},
+			secretCertPemAttr: {
+				Description: "PEM encoded client certificate, usually `cockroach_client_certificate.<name>.cert_pem`.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+			},
+			secretPrivateKeyPemAttr: {
+				Description: "PEM encoded private key of the client certificate, usually `cockroach_client_certificate.<name>.private_key_pem`.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				Default:     "",
+			},
+			secretCaCertPemAttr: {
+				Description: "PEM encoded CA certificate, usually `cockroach_client_certificate.<name>.ca_cert_pem`.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+			},
+		},
+	}
+}
+
+func resourceUserSecretCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	kubeClient, err := userSecretKubeClient(cockroachClient)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	namespace := d.Get(secretNamespaceAttr).(string)
+	if namespace == "" {
+		namespace = cockroachClient.kubeConn.nameSpace
+	}
+	name := d.Get(secretNameAttr).(string)
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      expandStringMap(d.Get(secretLabelsAttr).(map[string]interface{})),
+			Annotations: expandStringMap(d.Get(secretAnnotationsAttr).(map[string]interface{})),
+		},
+		Type: v1.SecretTypeOpaque,
+		Data: userSecretData(d),
+	}
+
+	if _, err := kubeClient.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		return diag.Errorf("cannot create secret %s/%s: %s", namespace, name, err)
+	}
+
+	d.SetId(namespace + "/" + name)
+	d.Set(secretNamespaceAttr, namespace)
+
+	return diag.Diagnostics{}
+}
+
+func resourceUserSecretRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	kubeClient, err := userSecretKubeClient(cockroachClient)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	namespace, name, err := parseUserSecretId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	secret, err := kubeClient.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		logInfo("secret %s not found, removing it from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set(secretNameAttr, name); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set(secretNamespaceAttr, namespace); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// controllers add their own labels and annotations, only the configured
+	// ones are read back
+	labels := managedEntries(secret.Labels, d.Get(secretLabelsAttr).(map[string]interface{}))
+	if err := d.Set(secretLabelsAttr, labels); err != nil {
+		return diag.FromErr(err)
+	}
+
+	annotations := managedEntries(secret.Annotations, d.Get(secretAnnotationsAttr).(map[string]interface{}))
+	if err := d.Set(secretAnnotationsAttr, annotations); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// credentials changed in the Secret show up as drift
+	for attr, key := range userSecretKeys {
+		if err := d.Set(attr, string(secret.Data[key])); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return nil
+}
+
+func resourceUserSecretUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	kubeClient, err := userSecretKubeClient(cockroachClient)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	namespace, name, err := parseUserSecretId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	secret, err := kubeClient.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return diag.Errorf("cannot read secret %s/%s: %s", namespace, name, err)
+	}
+
+	old_labels, new_labels := d.GetChange(secretLabelsAttr)
+	secret.Labels = mergeManagedEntries(secret.Labels, old_labels.(map[string]interface{}), new_labels.(map[string]interface{}))
+
+	old_annotations, new_annotations := d.GetChange(secretAnnotationsAttr)
+	secret.Annotations = mergeManagedEntries(secret.Annotations, old_annotations.(map[string]interface{}), new_annotations.(map[string]interface{}))
+
+	secret.Data = userSecretData(d)
+
+	if _, err := kubeClient.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return diag.Errorf("cannot update secret %s/%s: %s", namespace, name, err)
+	}
+
+	return diag.Diagnostics{}
+}
+
+func resourceUserSecretDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cockroachClient := meta.(*cockroachClient)
+
+	kubeClient, err := userSecretKubeClient(cockroachClient)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	namespace, name, err := parseUserSecretId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = kubeClient.CoreV1().Secrets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return diag.Errorf("cannot delete secret %s/%s: %s", namespace, name, err)
+	}
+
+	d.SetId("")
+
+	return diag.Diagnostics{}
+}
+
+func resourceUserSecretImporter(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	// id is <namespace>/<name>
+	if _, _, err := parseUserSecretId(d.Id()); err != nil {
+		return nil, err
+	}
+
+	diags := resourceUserSecretRead(ctx, d, meta)
+	if diags.HasError() {
+		return nil, fmt.Errorf("Unable to import resource")
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func userSecretKubeClient(cockroachClient *cockroachClient) (*kubernetes.Clientset, error) {
+	if cockroachClient.kubeConn.kubeClient == nil {
+		return nil, fmt.Errorf("cockroach_user_secret requires the kube_config of the provider")
+	}
+
+	return cockroachClient.kubeConn.kubeClient, nil
+}
+
+// userSecretData maps the credentials to the keys of the Secret, empty
+// credentials are left out.
+func userSecretData(d *schema.ResourceData) map[string][]byte {
+	data := map[string][]byte{}
+	for attr, key := range userSecretKeys {
+		if v := d.Get(attr).(string); v != "" {
+			data[key] = []byte(v)
+		}
+	}
+
+	return data
+}
+
+func parseUserSecretId(id string) (string, string, error) {
+	parts := strings.Split(id, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid id %s, expected <namespace>/<name>", id)
+	}
+
+	return parts[0], parts[1], nil
+}
+
+func expandStringMap(raw map[string]interface{}) map[string]string {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	val := make(map[string]string, len(raw))
+	for k, v := range raw {
+		val[k] = v.(string)
+	}
+
+	return val
+}
+
+// managedEntries returns the entries of live whose keys are managed by the
+// configuration.
+func managedEntries(live map[string]string, managed map[string]interface{}) map[string]string {
+	entries := map[string]string{}
+	for key := range managed {
+		if value, ok := live[key]; ok {
+			entries[key] = value
+		}
+	}
+
+	return entries
+}
+
+// mergeManagedEntries applies a change of the managed entries to live, the
+// entries added by others are kept.
+func mergeManagedEntries(live map[string]string, old map[string]interface{}, new map[string]interface{}) map[string]string {
+	merged := map[string]string{}
+	for key, value := range live {
+		if _, ok := old[key]; !ok {
+			merged[key] = value
+		}
+	}
+
+	for key, value := range new {
+		merged[key] = value.(string)
+	}
+
+	return merged
+}